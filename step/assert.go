@@ -0,0 +1,50 @@
+package step
+
+import "fmt"
+
+// assertVersionNumbers compares the effective version numbers of the targets to the expected ones, in each build
+// configuration, without writing any file.
+func (u Updater) assertVersionNumbers(targets []bundleTarget, config Config) error {
+	var mismatches []versionMismatch
+	for _, target := range targets {
+		configurations, err := targetConfigurations(target.helper, target.name, config.Configuration)
+		if err != nil {
+			return err
+		}
+
+		for _, configuration := range configurations {
+			actual, err := u.targetVersions(target.helper, config.Scheme, target.name, configuration)
+			if err != nil {
+				return fmt.Errorf("failed to resolve the version numbers of the %s configuration of the %s target: %w", configuration, target.name, err)
+			}
+
+			u.logger.Printf("%s (%s): build number %s (%s), version number %s (%s)", target.name, configuration,
+				displayVersion(actual.BuildVersion.Value), actual.BuildVersion.Location,
+				displayVersion(actual.ShortVersion.Value), actual.ShortVersion.Location)
+
+			configurationConfig := configurationVersions(config, configuration)
+			expected := targetVersions{
+				Target:        target.name,
+				Configuration: configuration,
+				BuildVersion:  versionValue{Value: configurationConfig.BuildVersion},
+				ShortVersion:  versionValue{Value: configurationConfig.BuildShortVersionString},
+			}
+			// The version number is only checked if it is provided, the same way it is only updated if it is provided.
+			if configurationConfig.BuildShortVersionString == "" {
+				expected.ShortVersion = actual.ShortVersion
+			}
+
+			if actual.BuildVersion.Value != expected.BuildVersion.Value || actual.ShortVersion.Value != expected.ShortVersion.Value {
+				mismatches = append(mismatches, versionMismatch{Expected: expected, Actual: actual})
+			}
+		}
+	}
+
+	if len(mismatches) > 0 {
+		return fmt.Errorf("%d target configuration(s) do not have the expected version numbers:\n%s", len(mismatches), versionMismatchTable(mismatches))
+	}
+
+	u.logger.Donef("Version numbers match the expected values.")
+
+	return nil
+}
@@ -0,0 +1,739 @@
+package step
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/bitrise-io/go-xcode/xcodeproject/serialized"
+	"github.com/bitrise-io/go-xcode/xcodeproject/xcodeproj"
+)
+
+const inheritedVariable = "inherited"
+
+var buildSettingReferenceRegexp = regexp.MustCompile(`^\$(?:\(([A-Za-z_][A-Za-z0-9_]*)\)|\{([A-Za-z_][A-Za-z0-9_]*)\}|([A-Za-z_][A-Za-z0-9_]*))$`)
+
+type buildSettingLevel int
+
+const (
+	projectXcconfigLevel buildSettingLevel = iota
+	projectLevel
+	targetXcconfigLevel
+	targetLevel
+)
+
+// buildSettingAssignment is a single `KEY[condition] = value` definition of a build setting, either in the project file
+// or in an xcconfig file.
+type buildSettingAssignment struct {
+	Key       string
+	Condition string
+	Value     string
+	Level     buildSettingLevel
+
+	// BuildConfiguration is set when the setting is defined in the project file.
+	BuildConfiguration *xcodeproj.BuildConfiguration
+	rawKey             string
+	// Xcconfig is set when the setting is defined in an xcconfig file.
+	Xcconfig *xcconfig
+	entry    xcconfigEntry
+}
+
+// Location returns a human-readable description of where the build setting is defined.
+func (a buildSettingAssignment) Location() string {
+	if a.Xcconfig != nil {
+		return fmt.Sprintf("%s:%d", a.Xcconfig.Path, a.entry.line+1)
+	}
+	if a.Level == projectLevel {
+		return fmt.Sprintf("%s configuration of the project", a.BuildConfiguration.Name)
+	}
+	return fmt.Sprintf("%s configuration of the target", a.BuildConfiguration.Name)
+}
+
+// isProjectLevel reports whether the setting is defined for every target of the project.
+func (a buildSettingAssignment) isProjectLevel() bool {
+	return a.Level == projectXcconfigLevel || a.Level == projectLevel
+}
+
+type undefinedBuildSettingError struct {
+	key string
+}
+
+func (e undefinedBuildSettingError) Error() string {
+	return fmt.Sprintf("build setting (%s) is not defined", e.key)
+}
+
+// buildSettings are the build settings of a target and configuration, evaluated the same way Xcode layers them:
+// project xcconfig, project, target xcconfig and target level settings, in ascending precedence.
+type buildSettings struct {
+	builtins    map[string]string
+	assignments []buildSettingAssignment
+}
+
+// definition returns the assignment that provides the effective value of the given build setting.
+func (s buildSettings) definition(key string) (buildSettingAssignment, bool) {
+	return s.conditionalDefinition(key, "")
+}
+
+// conditionalDefinition returns the assignment that provides the value of the given conditional variant of a build
+// setting, like `MARKETING_VERSION[sdk=macosx*]`.
+func (s buildSettings) conditionalDefinition(key, condition string) (buildSettingAssignment, bool) {
+	index := s.conditionalLookup(key, condition)
+	if index < 0 {
+		return buildSettingAssignment{}, false
+	}
+	return s.assignments[index], true
+}
+
+func (s buildSettings) conditionalLookup(key, condition string) int {
+	for i := len(s.assignments) - 1; i >= 0; i-- {
+		assignment := s.assignments[i]
+		if assignment.Key == key && assignment.Condition == condition {
+			return i
+		}
+	}
+	return -1
+}
+
+// referencedDefinition follows the build setting references, like `MARKETING_VERSION = $(APP_VERSION)`, from the
+// definition of the given build setting to the one holding the literal value. It also returns the chain of the followed
+// build settings.
+func (s buildSettings) referencedDefinition(key, condition string) (buildSettingAssignment, []string, error) {
+	index := s.conditionalLookup(key, condition)
+	if index < 0 {
+		return buildSettingAssignment{}, nil, undefinedBuildSettingError{key: key + condition}
+	}
+
+	chain := []string{key + condition}
+	visited := map[int]bool{index: true}
+
+	for {
+		reference, ok := buildSettingReference(s.assignments[index].Value)
+		if !ok {
+			return s.assignments[index], chain, nil
+		}
+
+		name := s.assignments[index].Key
+		var next int
+		if reference == inheritedVariable {
+			next = s.lookup(name, index)
+		} else {
+			name = reference
+			next = s.lookup(name, len(s.assignments))
+		}
+
+		chain = append(chain, name)
+		if next < 0 {
+			if _, ok := s.builtins[name]; ok {
+				return buildSettingAssignment{}, nil, fmt.Errorf("build setting reference chain (%s) ends in %s, which is defined by Xcode", strings.Join(chain, " -> "), name)
+			}
+			return buildSettingAssignment{}, nil, fmt.Errorf("build setting reference chain (%s) cannot be resolved: %s is not defined", strings.Join(chain, " -> "), name)
+		}
+		if visited[next] {
+			return buildSettingAssignment{}, nil, fmt.Errorf("build setting reference chain (%s) contains a cycle", strings.Join(chain, " -> "))
+		}
+
+		visited[next] = true
+		index = next
+	}
+}
+
+// conditions returns the distinct conditions the given build setting has conditional variants for.
+func (s buildSettings) conditions(key string) []string {
+	var conditions []string
+	seen := map[string]bool{}
+	for _, assignment := range s.assignments {
+		if assignment.Key != key || assignment.Condition == "" || seen[assignment.Condition] {
+			continue
+		}
+		seen[assignment.Condition] = true
+		conditions = append(conditions, assignment.Condition)
+	}
+	return conditions
+}
+
+// value returns the effective value of the given build setting with every build setting reference expanded.
+func (s buildSettings) value(key string) (string, error) {
+	return s.resolve(key, len(s.assignments), nil)
+}
+
+// expand expands the build setting references in an arbitrary string, for example in an Info.plist value.
+func (s buildSettings) expand(value string) (string, error) {
+	return expandBuildSettingReferences(value, func(name string) (string, error) {
+		return s.resolve(name, len(s.assignments), nil)
+	})
+}
+
+// lookup returns the index of the last unconditional assignment of the given build setting before end.
+func (s buildSettings) lookup(key string, end int) int {
+	for i := end - 1; i >= 0; i-- {
+		assignment := s.assignments[i]
+		if assignment.Key == key && assignment.Condition == "" {
+			return i
+		}
+	}
+	return -1
+}
+
+func (s buildSettings) resolve(key string, end int, stack []string) (string, error) {
+	frame := fmt.Sprintf("%s@%d", key, end)
+	for _, previous := range stack {
+		if previous == frame {
+			return "", fmt.Errorf("build setting (%s) references itself: %s", key, strings.Join(append(stack, frame), " -> "))
+		}
+	}
+	stack = append(stack, frame)
+
+	index := s.lookup(key, end)
+	if index < 0 {
+		builtin, ok := s.builtins[key]
+		if !ok {
+			return "", undefinedBuildSettingError{key: key}
+		}
+		return builtin, nil
+	}
+
+	return expandBuildSettingReferences(s.assignments[index].Value, func(name string) (string, error) {
+		if name == inheritedVariable {
+			value, err := s.resolve(key, index, stack)
+			if _, ok := err.(undefinedBuildSettingError); ok {
+				return "", nil
+			}
+			return value, err
+		}
+		return s.resolve(name, len(s.assignments), stack)
+	})
+}
+
+// buildSettingsResolver evaluates target build settings from the project file and the referenced xcconfig files,
+// without calling xcodebuild. Like Xcode, it skips the xcconfig files which cannot be read, for example the CocoaPods
+// xcconfig files before `pod install`.
+type buildSettingsResolver struct {
+	project          xcodeproj.XcodeProj
+	files            *fileStore
+	logger           log.Logger
+	xcconfigs        map[string]*xcconfig
+	changedXcconfigs map[string]bool
+	projectChanged   bool
+	parents          map[string]string
+}
+
+func newBuildSettingsResolver(project xcodeproj.XcodeProj, files *fileStore, logger log.Logger) *buildSettingsResolver {
+	return &buildSettingsResolver{
+		project:          project,
+		files:            files,
+		logger:           logger,
+		xcconfigs:        map[string]*xcconfig{},
+		changedXcconfigs: map[string]bool{},
+	}
+}
+
+// setValue updates a build setting where it is defined: either in the project file or in an xcconfig file. The changes
+// are persisted by save.
+func (r *buildSettingsResolver) setValue(assignment buildSettingAssignment, value string) {
+	if assignment.Xcconfig != nil {
+		assignment.Xcconfig.setValue(assignment.entry, value)
+		r.changedXcconfigs[assignment.Xcconfig.Path] = true
+		return
+	}
+
+	r.setProjectValue(*assignment.BuildConfiguration, assignment.rawKey, value)
+}
+
+// setProjectValue sets a build setting of a build configuration in the project file.
+func (r *buildSettingsResolver) setProjectValue(buildConfig xcodeproj.BuildConfiguration, key, value string) {
+	if current, ok := buildConfig.BuildSettings[key]; ok && buildSettingValueString(current) == value {
+		return
+	}
+
+	buildConfig.BuildSettings[key] = value
+	r.projectChanged = true
+}
+
+// save writes the project file, if any of its build settings changed, and the changed xcconfig files. It returns the
+// paths of the written files.
+func (r *buildSettingsResolver) save() ([]string, error) {
+	var paths []string
+	if r.projectChanged {
+		if err := r.files.saveProject(r.project); err != nil {
+			return nil, err
+		}
+		r.projectChanged = false
+		paths = append(paths, filepath.Join(r.project.Path, "project.pbxproj"))
+	}
+
+	var xcconfigPaths []string
+	for pth := range r.changedXcconfigs {
+		xcconfigPaths = append(xcconfigPaths, pth)
+	}
+	sort.Strings(xcconfigPaths)
+
+	for _, pth := range xcconfigPaths {
+		if err := r.files.writeFile(pth, r.xcconfigs[pth].content()); err != nil {
+			return nil, fmt.Errorf("failed to write xcconfig file: %w", err)
+		}
+		delete(r.changedXcconfigs, pth)
+	}
+
+	return append(paths, xcconfigPaths...), nil
+}
+
+func (r *buildSettingsResolver) targetBuildSettings(targetName, configuration string) (buildSettings, error) {
+	target, ok := r.project.Proj.TargetByName(targetName)
+	if !ok {
+		return buildSettings{}, fmt.Errorf("target '%s' not found in project: %s", targetName, r.project.Path)
+	}
+
+	targetConfig := findBuildConfiguration(target.BuildConfigurationList, configuration)
+	if targetConfig == nil {
+		return buildSettings{}, fmt.Errorf("build configuration '%s' not found for target '%s' in project: %s", configuration, targetName, r.project.Path)
+	}
+
+	projectConfigList := r.project.Proj.BuildConfigurationList
+	projectConfig := findBuildConfiguration(projectConfigList, configuration)
+	if projectConfig == nil {
+		projectConfig = findBuildConfiguration(projectConfigList, projectConfigList.DefaultConfigurationName)
+	}
+
+	var assignments []buildSettingAssignment
+	if projectConfig != nil {
+		projectAssignments, err := r.buildConfigurationAssignments(projectConfig, projectXcconfigLevel, projectLevel)
+		if err != nil {
+			return buildSettings{}, err
+		}
+		assignments = append(assignments, projectAssignments...)
+	}
+
+	targetAssignments, err := r.buildConfigurationAssignments(targetConfig, targetXcconfigLevel, targetLevel)
+	if err != nil {
+		return buildSettings{}, err
+	}
+	assignments = append(assignments, targetAssignments...)
+
+	projectDir := filepath.Dir(r.project.Path)
+
+	return buildSettings{
+		builtins: map[string]string{
+			"SRCROOT":           projectDir,
+			"SOURCE_ROOT":       projectDir,
+			"PROJECT_DIR":       projectDir,
+			"PROJECT_FILE_PATH": r.project.Path,
+			"PROJECT_NAME":      r.project.Name,
+			"TARGET_NAME":       target.Name,
+			"CONFIGURATION":     targetConfig.Name,
+		},
+		assignments: assignments,
+	}, nil
+}
+
+func (r *buildSettingsResolver) buildConfigurationAssignments(buildConfig *xcodeproj.BuildConfiguration, xcconfigLevel, level buildSettingLevel) ([]buildSettingAssignment, error) {
+	var assignments []buildSettingAssignment
+
+	xcconfigPath, err := r.baseConfigurationPath(buildConfig.ID)
+	if err != nil {
+		return nil, err
+	}
+	if xcconfigPath != "" {
+		xcconfigAssignments, err := r.xcconfigAssignments(xcconfigPath, xcconfigLevel, nil)
+		if err != nil {
+			return nil, err
+		}
+		assignments = append(assignments, xcconfigAssignments...)
+	}
+
+	keys := buildConfig.BuildSettings.Keys()
+	sort.Strings(keys)
+
+	for _, rawKey := range keys {
+		key, condition := splitBuildSettingKey(rawKey)
+		assignments = append(assignments, buildSettingAssignment{
+			Key:                key,
+			Condition:          condition,
+			Value:              buildSettingValueString(buildConfig.BuildSettings[rawKey]),
+			Level:              level,
+			BuildConfiguration: buildConfig,
+			rawKey:             rawKey,
+		})
+	}
+
+	return assignments, nil
+}
+
+func (r *buildSettingsResolver) xcconfigAssignments(pth string, level buildSettingLevel, includeStack []string) ([]buildSettingAssignment, error) {
+	for _, included := range includeStack {
+		if included == pth {
+			return nil, fmt.Errorf("xcconfig include cycle: %s", strings.Join(append(includeStack, pth), " -> "))
+		}
+	}
+	includeStack = append(includeStack, pth)
+
+	config, err := r.xcconfig(pth)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return nil, nil
+	}
+
+	var assignments []buildSettingAssignment
+	for _, entry := range config.entries {
+		if entry.isInclude() {
+			includePath := config.includePath(entry)
+			if entry.optional && !fileExists(includePath) {
+				continue
+			}
+
+			included, err := r.xcconfigAssignments(includePath, level, includeStack)
+			if err != nil {
+				return nil, err
+			}
+			assignments = append(assignments, included...)
+			continue
+		}
+
+		assignments = append(assignments, buildSettingAssignment{
+			Key:       entry.key,
+			Condition: entry.condition,
+			Value:     config.value(entry),
+			Level:     level,
+			Xcconfig:  config,
+			entry:     entry,
+		})
+	}
+
+	return assignments, nil
+}
+
+// xcconfig returns the parsed xcconfig file, or nil if the file cannot be read.
+func (r *buildSettingsResolver) xcconfig(pth string) (*xcconfig, error) {
+	if config, ok := r.xcconfigs[pth]; ok {
+		return config, nil
+	}
+
+	content, err := r.files.readFile(pth)
+	if err != nil {
+		r.logger.Warnf("Skipping the xcconfig file, it cannot be read: %s", err)
+		r.xcconfigs[pth] = nil
+		return nil, nil
+	}
+
+	config, err := parseXcconfigContent(pth, content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read xcconfig file: %w", err)
+	}
+	r.xcconfigs[pth] = config
+
+	return config, nil
+}
+
+// baseConfigurationPath returns the path of the xcconfig file the build configuration is based on, or an empty string if
+// it has none.
+func (r *buildSettingsResolver) baseConfigurationPath(buildConfigID string) (string, error) {
+	objects, err := r.project.RawProj.Object("objects")
+	if err != nil {
+		return "", err
+	}
+
+	rawBuildConfig, err := objects.Object(buildConfigID)
+	if err != nil {
+		return "", err
+	}
+
+	if referenceID, err := rawBuildConfig.String("baseConfigurationReference"); err == nil {
+		return r.fileReferencePath(objects, referenceID)
+	} else if !serialized.IsKeyNotFoundError(err) {
+		return "", err
+	}
+
+	// Since Xcode 16 the xcconfig file can be located in a synchronized folder, in which case it is referenced by the
+	// folder and a path relative to it.
+	anchorID, err := rawBuildConfig.String("baseConfigurationReferenceAnchor")
+	if err != nil {
+		if serialized.IsKeyNotFoundError(err) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	relativePath, err := rawBuildConfig.String("baseConfigurationReferenceRelativePath")
+	if err != nil {
+		return "", err
+	}
+
+	anchorPath, err := r.fileReferencePath(objects, anchorID)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(anchorPath, relativePath), nil
+}
+
+func (r *buildSettingsResolver) fileReferencePath(objects serialized.Object, id string) (string, error) {
+	reference, err := objects.Object(id)
+	if err != nil {
+		return "", err
+	}
+
+	pth, err := reference.String("path")
+	if err != nil && !serialized.IsKeyNotFoundError(err) {
+		return "", err
+	}
+
+	sourceTree, err := reference.String("sourceTree")
+	if err != nil && !serialized.IsKeyNotFoundError(err) {
+		return "", err
+	}
+
+	projectDir := filepath.Dir(r.project.Path)
+
+	switch sourceTree {
+	case "<absolute>":
+		return pth, nil
+	case "SOURCE_ROOT":
+		return filepath.Join(projectDir, pth), nil
+	case "<group>", "":
+		parentID := r.parentGroup(objects, id)
+		if parentID == "" {
+			return filepath.Join(projectDir, pth), nil
+		}
+
+		parentPath, err := r.fileReferencePath(objects, parentID)
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(parentPath, pth), nil
+	default:
+		return "", fmt.Errorf("unsupported source tree (%s) of file reference: %s", sourceTree, pth)
+	}
+}
+
+func (r *buildSettingsResolver) parentGroup(objects serialized.Object, id string) string {
+	if r.parents == nil {
+		r.parents = map[string]string{}
+
+		for parentID := range objects {
+			object, err := objects.Object(parentID)
+			if err != nil {
+				continue
+			}
+
+			children, err := object.StringSlice("children")
+			if err != nil {
+				continue
+			}
+
+			for _, childID := range children {
+				r.parents[childID] = parentID
+			}
+		}
+	}
+
+	return r.parents[id]
+}
+
+func findBuildConfiguration(configurationList xcodeproj.ConfigurationList, name string) *xcodeproj.BuildConfiguration {
+	for i, buildConfig := range configurationList.BuildConfigurations {
+		if buildConfig.Name == name {
+			return &configurationList.BuildConfigurations[i]
+		}
+	}
+	return nil
+}
+
+func buildSettingValueString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []interface{}:
+		var elements []string
+		for _, element := range v {
+			elements = append(elements, buildSettingValueString(element))
+		}
+		return strings.Join(elements, " ")
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// expandBuildSettingReferences replaces the `$(VAR)`, `${VAR}` and `$VAR` references in value using the provided lookup
+// function. References can be nested (`$(A_$(B))`) and can have operators (`$(VAR:lower)`).
+func expandBuildSettingReferences(value string, lookup func(name string) (string, error)) (string, error) {
+	var expanded strings.Builder
+
+	for i := 0; i < len(value); i++ {
+		if value[i] != '$' || i+1 == len(value) {
+			expanded.WriteByte(value[i])
+			continue
+		}
+
+		switch next := value[i+1]; {
+		case next == '(' || next == '{':
+			end := closingBracket(value, i+1)
+			if end < 0 {
+				return "", fmt.Errorf("unterminated build setting reference: %s", value[i:])
+			}
+
+			reference, err := expandBuildSettingReferences(value[i+2:end], lookup)
+			if err != nil {
+				return "", err
+			}
+
+			resolved, err := resolveBuildSettingReference(reference, lookup)
+			if err != nil {
+				return "", err
+			}
+
+			expanded.WriteString(resolved)
+			i = end
+		case isBuildSettingNameStart(next):
+			end := i + 1
+			for end < len(value) && isBuildSettingNameCharacter(value[end]) {
+				end++
+			}
+
+			resolved, err := lookup(value[i+1 : end])
+			if err != nil {
+				return "", err
+			}
+
+			expanded.WriteString(resolved)
+			i = end - 1
+		default:
+			expanded.WriteByte(value[i])
+		}
+	}
+
+	return expanded.String(), nil
+}
+
+func resolveBuildSettingReference(reference string, lookup func(name string) (string, error)) (string, error) {
+	parts := strings.Split(reference, ":")
+	name, operators := parts[0], parts[1:]
+
+	value, err := lookup(name)
+	if err != nil {
+		if _, ok := err.(undefinedBuildSettingError); !ok || !hasDefaultOperator(operators) {
+			return "", err
+		}
+	}
+
+	for _, operator := range operators {
+		value, err = applyBuildSettingOperator(value, operator)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return value, nil
+}
+
+func hasDefaultOperator(operators []string) bool {
+	for _, operator := range operators {
+		if strings.HasPrefix(operator, "default=") {
+			return true
+		}
+	}
+	return false
+}
+
+func applyBuildSettingOperator(value, operator string) (string, error) {
+	if strings.HasPrefix(operator, "default=") {
+		if value == "" {
+			return strings.TrimPrefix(operator, "default="), nil
+		}
+		return value, nil
+	}
+
+	switch operator {
+	case "lower":
+		return strings.ToLower(value), nil
+	case "upper":
+		return strings.ToUpper(value), nil
+	case "dir":
+		return filepath.Dir(value) + "/", nil
+	case "file":
+		return filepath.Base(value), nil
+	case "base":
+		return strings.TrimSuffix(filepath.Base(value), filepath.Ext(value)), nil
+	case "suffix":
+		return filepath.Ext(value), nil
+	case "standardizepath":
+		return filepath.Clean(value), nil
+	case "c99extidentifier", "identifier":
+		return replaceInvalidCharacters(value, '_', false), nil
+	case "rfc1034identifier":
+		return replaceInvalidCharacters(value, '-', true), nil
+	default:
+		return "", fmt.Errorf("unsupported build setting operator: %s", operator)
+	}
+}
+
+func replaceInvalidCharacters(value string, replacement rune, allowDash bool) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case r == '-' && allowDash:
+			return r
+		case r == '_' && !allowDash:
+			return r
+		case r == '.' && allowDash:
+			return r
+		default:
+			return replacement
+		}
+	}, value)
+}
+
+func closingBracket(value string, open int) int {
+	opening := value[open]
+	closing := byte(')')
+	if opening == '{' {
+		closing = '}'
+	}
+
+	depth := 0
+	for i := open; i < len(value); i++ {
+		switch value[i] {
+		case opening:
+			depth++
+		case closing:
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func isBuildSettingNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isBuildSettingNameCharacter(c byte) bool {
+	return isBuildSettingNameStart(c) || (c >= '0' && c <= '9')
+}
+
+// buildSettingReference returns the name of the build setting if value is a single build setting reference, like
+// `$(MARKETING_VERSION)` or `${MARKETING_VERSION}`.
+func buildSettingReference(value interface{}) (string, bool) {
+	str, ok := value.(string)
+	if !ok {
+		return "", false
+	}
+
+	match := buildSettingReferenceRegexp.FindStringSubmatch(strings.TrimSpace(str))
+	if match == nil {
+		return "", false
+	}
+
+	for _, name := range match[1:] {
+		if name != "" {
+			return name, true
+		}
+	}
+	return "", false
+}
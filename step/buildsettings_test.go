@@ -0,0 +1,176 @@
+package step
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/bitrise-io/go-xcode/xcodeproject/xcodeproj"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_buildSettingsResolver_targetBuildSettings(t *testing.T) {
+	projectPath, err := filepath.Abs("../testdata/project/Example/Example.xcodeproj")
+	require.NoError(t, err)
+
+	project, err := xcodeproj.Open(projectPath)
+	require.NoError(t, err)
+
+	resolver := newBuildSettingsResolver(project, nil, log.NewLogger())
+
+	settings, err := resolver.targetBuildSettings("Example", "Release")
+	require.NoError(t, err)
+
+	infoPlistPath, err := settings.value(infoPlistFileKey)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(filepath.Dir(projectPath), "Example/Info.plist"), infoPlistPath)
+
+	productName, err := settings.value("PRODUCT_NAME")
+	require.NoError(t, err)
+	require.Equal(t, "Example", productName)
+
+	settings, err = resolver.targetBuildSettings("Example-Static", "Debug")
+	require.NoError(t, err)
+
+	infoPlistPath, err = settings.value(infoPlistFileKey)
+	require.NoError(t, err)
+	require.Equal(t, "Example/Example-Static-Info.plist", infoPlistPath)
+
+	definition, ok := settings.definition(infoPlistFileKey)
+	require.True(t, ok)
+	require.Equal(t, targetXcconfigLevel, definition.Level)
+	require.Equal(t, filepath.Join(filepath.Dir(projectPath), "Example/Config.xcconfig"), definition.Xcconfig.Path)
+}
+
+func Test_buildSettingsResolver_unreadableXcconfig(t *testing.T) {
+	projectDir := copyTestProject(t)
+	projectPath := filepath.Join(projectDir, "Example.xcodeproj")
+	xcconfigPath := filepath.Join(projectDir, "Example/Config.xcconfig")
+
+	tests := []struct {
+		name     string
+		xcconfig string
+	}{
+		{
+			name: "missing base xcconfig",
+		},
+		{
+			name:     "missing include",
+			xcconfig: "#include \"Pods/Target Support Files/Pods-Example/Pods-Example.release.xcconfig\"\nPRODUCT_NAME = Example\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, os.RemoveAll(xcconfigPath))
+			if tt.xcconfig != "" {
+				writeFile(t, xcconfigPath, tt.xcconfig)
+			}
+
+			project, err := xcodeproj.Open(projectPath)
+			require.NoError(t, err)
+
+			settings, err := newBuildSettingsResolver(project, nil, log.NewLogger()).targetBuildSettings("Example-Static", "Release")
+			require.NoError(t, err)
+
+			_, err = settings.value(infoPlistFileKey)
+			require.Equal(t, undefinedBuildSettingError{key: infoPlistFileKey}, err)
+		})
+	}
+}
+
+func Test_buildSettings_value(t *testing.T) {
+	settings := buildSettings{
+		builtins: map[string]string{"SRCROOT": "/src", "TARGET_NAME": "App"},
+		assignments: []buildSettingAssignment{
+			{Key: "FLAGS", Value: "-a", Level: projectXcconfigLevel},
+			{Key: "FLAGS", Value: "$(inherited) -b", Level: projectLevel},
+			{Key: "FLAGS", Value: "${inherited} -c", Level: targetLevel},
+			{Key: "PLIST", Value: "$SRCROOT/$(TARGET_NAME)/Info.plist", Level: targetLevel},
+			{Key: "NAME", Value: "$(TARGET_NAME:lower)", Level: targetLevel},
+			{Key: "SUFFIX", Value: "Dev", Level: targetLevel},
+			{Key: "NESTED_Dev", Value: "nested", Level: targetLevel},
+			{Key: "NESTED", Value: "$(NESTED_$(SUFFIX))", Level: targetLevel},
+			{Key: "MISSING", Value: "$(UNKNOWN)", Level: targetLevel},
+			{Key: "DEFAULTED", Value: "$(UNKNOWN:default=value)", Level: targetLevel},
+			{Key: "CYCLE_A", Value: "$(CYCLE_B)", Level: targetLevel},
+			{Key: "CYCLE_B", Value: "$(CYCLE_A)", Level: targetLevel},
+		},
+	}
+
+	tests := []struct {
+		key     string
+		want    string
+		wantErr bool
+	}{
+		{key: "FLAGS", want: "-a -b -c"},
+		{key: "PLIST", want: "/src/App/Info.plist"},
+		{key: "NAME", want: "app"},
+		{key: "NESTED", want: "nested"},
+		{key: "DEFAULTED", want: "value"},
+		{key: "MISSING", wantErr: true},
+		{key: "CYCLE_A", wantErr: true},
+		{key: "NOT_DEFINED", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			got, err := settings.value(tt.key)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_buildSettings_referencedDefinition(t *testing.T) {
+	settings := buildSettings{
+		builtins: map[string]string{"TARGET_NAME": "App"},
+		assignments: []buildSettingAssignment{
+			{Key: "APP_VERSION", Value: "1.0.0", Level: projectXcconfigLevel},
+			{Key: "APP_BUILD", Value: "1", Level: projectLevel},
+			{Key: "APP_BUILD", Value: "$(inherited)", Level: targetLevel},
+			{Key: "SHARED_VERSION", Value: "$(APP_VERSION)", Level: targetLevel},
+			{Key: "MARKETING_VERSION", Value: "$(SHARED_VERSION)", Level: targetLevel},
+			{Key: "MARKETING_VERSION", Condition: "[sdk=macosx*]", Value: "${APP_VERSION}", Level: targetLevel},
+			{Key: "CURRENT_PROJECT_VERSION", Value: "$(APP_BUILD)", Level: targetLevel},
+			{Key: "CYCLE_A", Value: "$(CYCLE_B)", Level: targetLevel},
+			{Key: "CYCLE_B", Value: "$(CYCLE_A)", Level: targetLevel},
+			{Key: "UNDEFINED", Value: "$(MISSING)", Level: targetLevel},
+			{Key: "BUILTIN", Value: "$(TARGET_NAME)", Level: targetLevel},
+		},
+	}
+
+	tests := []struct {
+		key       string
+		condition string
+		wantChain []string
+		wantLevel buildSettingLevel
+		wantErr   bool
+	}{
+		{key: "MARKETING_VERSION", wantChain: []string{"MARKETING_VERSION", "SHARED_VERSION", "APP_VERSION"}, wantLevel: projectXcconfigLevel},
+		{key: "MARKETING_VERSION", condition: "[sdk=macosx*]", wantChain: []string{"MARKETING_VERSION[sdk=macosx*]", "APP_VERSION"}, wantLevel: projectXcconfigLevel},
+		{key: "CURRENT_PROJECT_VERSION", wantChain: []string{"CURRENT_PROJECT_VERSION", "APP_BUILD", "APP_BUILD"}, wantLevel: projectLevel},
+		{key: "APP_VERSION", wantChain: []string{"APP_VERSION"}, wantLevel: projectXcconfigLevel},
+		{key: "CYCLE_A", wantErr: true},
+		{key: "UNDEFINED", wantErr: true},
+		{key: "BUILTIN", wantErr: true},
+		{key: "NOT_DEFINED", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key+tt.condition, func(t *testing.T) {
+			definition, chain, err := settings.referencedDefinition(tt.key, tt.condition)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tt.wantChain, chain)
+			require.Equal(t, tt.wantLevel, definition.Level)
+		})
+	}
+}
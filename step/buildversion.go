@@ -0,0 +1,195 @@
+package step
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+)
+
+// dottedBuildVersionRegexp matches a build number of period-separated integers, like 1.2.3.45.
+var dottedBuildVersionRegexp = regexp.MustCompile(`^\d+(\.\d+)+$`)
+
+// buildVersion returns the build number to set, according to the build version mode.
+func (u Updater) buildVersion(targets []bundleTarget, config Config) (string, error) {
+	if config.BuildVersionMode == BuildVersionModeIncrementExisting {
+		if config.BuildVersionSource != "" && config.BuildVersionSource != BuildVersionSourceInput {
+			u.logger.Warnf("Build version source (%s) is ignored in the %s build version mode", config.BuildVersionSource, BuildVersionModeIncrementExisting)
+		}
+		return u.incrementExistingBuildVersion(targets, config)
+	}
+
+	buildVersion, err := u.sourceBuildVersion(config)
+	if err != nil {
+		return "", err
+	}
+
+	if config.BuildVersionMode == BuildVersionModeFormula {
+		ciBuildVersion, err := incrementBuildVersion(u.logger, buildVersion, config.BuildVersionOffset, config.BuildVersionOffsetComponent)
+		if err != nil {
+			return "", err
+		}
+		return u.formulaBuildVersion(targets, config, ciBuildVersion)
+	}
+
+	if config.BuildVersionMode == BuildVersionModeSet {
+		if config.BuildVersionOffset != 0 {
+			u.logger.Warnf("Build version offset (%d) is ignored in the %s build version mode", config.BuildVersionOffset, BuildVersionModeSet)
+		}
+		return buildVersion, nil
+	}
+
+	if config.BuildVersionOffset < 0 {
+		u.logger.Warnf("Using a negative build version offset to set the build version as-is is deprecated, use the %s build version mode instead", BuildVersionModeSet)
+	}
+	return incrementBuildVersion(u.logger, buildVersion, config.BuildVersionOffset, config.BuildVersionOffsetComponent)
+}
+
+// sourceBuildVersion returns the build number the offset is applied to: the build_version input, or a value derived
+// from the history of the local git repository containing the project.
+func (u Updater) sourceBuildVersion(config Config) (string, error) {
+	repository := gitRepository{dir: filepath.Dir(config.ProjectPath)}
+
+	switch config.BuildVersionSource {
+	case BuildVersionSourceGitCommitCount:
+		count, err := repository.commitCount(config.GitBaseRef)
+		if err != nil {
+			return "", fmt.Errorf("failed to count the git commits: %w", err)
+		}
+
+		buildVersion := strconv.FormatInt(count, 10)
+		if config.GitBaseRef != "" {
+			u.logger.Printf("Build number from the number of commits since %s: %s", config.GitBaseRef, buildVersion)
+		} else {
+			u.logger.Printf("Build number from the number of commits: %s", buildVersion)
+		}
+		return buildVersion, nil
+	case BuildVersionSourceGitCommitTimestamp:
+		commitTime, err := repository.commitTime()
+		if err != nil {
+			return "", fmt.Errorf("failed to read the git commit timestamp: %w", err)
+		}
+
+		buildVersion := commitTime.UTC().Format("20060102.1504")
+		u.logger.Printf("Build number from the commit timestamp (%s): %s", commitTime.UTC().Format(time.RFC3339), buildVersion)
+		return buildVersion, nil
+	default:
+		return config.BuildVersion, nil
+	}
+}
+
+// incrementExistingBuildVersion increases the current build number of the targets by the offset, or by 1 if there is no
+// offset. A dotted build number is incremented in the build_version_offset_component component. Every target and
+// configuration has to have the same current build number.
+func (u Updater) incrementExistingBuildVersion(targets []bundleTarget, config Config) (string, error) {
+	if config.BuildVersionOffset < 0 {
+		return "", fmt.Errorf("build version offset (%d) cannot be negative in the %s build version mode", config.BuildVersionOffset, BuildVersionModeIncrementExisting)
+	}
+
+	allVersions, err := u.allTargetVersions(targets, config)
+	if err != nil {
+		return "", err
+	}
+
+	current, err := currentVersion(allVersions, "build number", func(versions targetVersions) versionValue {
+		return versions.BuildVersion
+	})
+	if err != nil {
+		return "", fmt.Errorf("the current build number cannot be incremented: %w", err)
+	}
+
+	offset := config.BuildVersionOffset
+	if offset == 0 {
+		offset = 1
+	}
+
+	if dottedBuildVersionRegexp.MatchString(current) {
+		return incrementDottedBuildVersion(u.logger, current, offset, config.BuildVersionOffsetComponent)
+	}
+
+	parsedCurrent, err := strconv.ParseInt(current, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("the current build number (%s) is not numeric, it cannot be incremented", displayVersion(current))
+	}
+
+	buildVersion := strconv.FormatInt(parsedCurrent+offset, 10)
+	u.logger.Printf("Incrementing the current build number (%s) by %d: %s", current, offset, buildVersion)
+
+	return buildVersion, nil
+}
+
+// incrementDottedBuildVersion adds the offset to a component of a dotted build number, the last one if the component is
+// 0. The other components and the zero padding of the incremented component are kept.
+func incrementDottedBuildVersion(logger log.Logger, buildVersion string, offset int64, component int) (string, error) {
+	if offset == 0 {
+		return buildVersion, nil
+	}
+	if offset < 0 {
+		logger.Infof("Build version offset is negative (%d), skipping version increment.", offset)
+		return buildVersion, nil
+	}
+
+	components := strings.Split(buildVersion, ".")
+	index := len(components) - 1
+	if component > 0 {
+		if component > len(components) {
+			return "", fmt.Errorf("build version offset component (%d) is out of range, the build version (%s) has %d components", component, buildVersion, len(components))
+		}
+		index = component - 1
+	}
+
+	value, err := strconv.ParseInt(components[index], 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("invalid build version component (%s): %w", components[index], err)
+	}
+
+	incremented := value + offset
+	if incremented > maxBundleVersionComponentValue {
+		return "", fmt.Errorf("build version component %d (%s) would be %d with the offset, which is greater than the maximum (%d)", index+1, components[index], incremented, maxBundleVersionComponentValue)
+	}
+	components[index] = fmt.Sprintf("%0*d", len(components[index]), incremented)
+
+	incrementedBuildVersion := strings.Join(components, ".")
+	if len(incrementedBuildVersion) > maxBundleVersionLength {
+		return "", fmt.Errorf("build version (%s) would be longer than %d characters", incrementedBuildVersion, maxBundleVersionLength)
+	}
+
+	logger.Printf("Adding the offset (%d) to component %d of the build version (%s): %s", offset, index+1, buildVersion, incrementedBuildVersion)
+
+	return incrementedBuildVersion, nil
+}
+
+// currentVersion returns the current value of a version number of the targets, which has to be the same in every target
+// and configuration.
+func currentVersion(allVersions []targetVersions, name string, version func(targetVersions) versionValue) (string, error) {
+	current := version(allVersions[0]).Value
+	for _, versions := range allVersions[1:] {
+		if version(versions).Value != current {
+			return "", fmt.Errorf("the targets have different %ss:\n%s", name, currentVersionTable(allVersions, name, version))
+		}
+	}
+	return current, nil
+}
+
+func currentVersionTable(allVersions []targetVersions, name string, version func(targetVersions) versionValue) string {
+	var table strings.Builder
+	writer := tabwriter.NewWriter(&table, 0, 0, 2, ' ', 0)
+
+	_, _ = fmt.Fprintf(writer, "Target\tConfiguration\t%s\tDefined in\n", strings.ToUpper(name[:1])+name[1:])
+	for _, versions := range allVersions {
+		_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n",
+			versions.Target,
+			versions.Configuration,
+			displayVersion(version(versions).Value),
+			version(versions).Location,
+		)
+	}
+	_ = writer.Flush()
+
+	return table.String()
+}
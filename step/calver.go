@@ -0,0 +1,103 @@
+package step
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// calendarVersions calculates the next calendar version number (YYYY.MM.N) and build number (YYYYMMDDNN) from the
+// current date and the current version numbers of the targets. It returns the current and the new version numbers.
+func (u Updater) calendarVersions(targets []bundleTarget, config Config, now time.Time) (targetVersions, targetVersions, error) {
+	allVersions, err := u.allTargetVersions(targets, config)
+	if err != nil {
+		return targetVersions{}, targetVersions{}, err
+	}
+
+	var current targetVersions
+	current.BuildVersion.Value, err = currentVersion(allVersions, "build number", func(versions targetVersions) versionValue {
+		return versions.BuildVersion
+	})
+	if err != nil {
+		return targetVersions{}, targetVersions{}, fmt.Errorf("the calendar version cannot be calculated: %w", err)
+	}
+
+	current.ShortVersion.Value, err = currentVersion(allVersions, "version number", func(versions targetVersions) versionValue {
+		return versions.ShortVersion
+	})
+	if err != nil {
+		return targetVersions{}, targetVersions{}, fmt.Errorf("the calendar version cannot be calculated: %w", err)
+	}
+
+	var next targetVersions
+	next.ShortVersion.Value, err = nextCalendarVersion(current.ShortVersion.Value, now)
+	if err != nil {
+		return targetVersions{}, targetVersions{}, err
+	}
+
+	next.BuildVersion.Value, err = nextCalendarBuildVersion(current.BuildVersion.Value, now)
+	if err != nil {
+		return targetVersions{}, targetVersions{}, err
+	}
+
+	u.logger.Printf("Calendar version number: %s -> %s", displayVersion(current.ShortVersion.Value), next.ShortVersion.Value)
+	u.logger.Printf("Calendar build number: %s -> %s", displayVersion(current.BuildVersion.Value), next.BuildVersion.Value)
+
+	return current, next, nil
+}
+
+// nextCalendarVersion returns the YYYY.MM.N version number following the current one: N is incremented if the current
+// version number is from the same month, otherwise it starts again from 1.
+func nextCalendarVersion(current string, now time.Time) (string, error) {
+	year, month := now.Year(), int(now.Month())
+
+	components := strings.Split(current, ".")
+	if len(components) == 3 && len(components[0]) == 4 {
+		currentYear, yearErr := strconv.Atoi(components[0])
+		currentMonth, monthErr := strconv.Atoi(components[1])
+		n, nErr := strconv.Atoi(components[2])
+		if yearErr == nil && monthErr == nil && nErr == nil {
+			switch {
+			case currentYear == year && currentMonth == month:
+				return fmt.Sprintf("%d.%d.%d", year, month, n+1), nil
+			case currentYear > year || (currentYear == year && currentMonth > month):
+				return "", fmt.Errorf("the current version number (%s) is later than the current date (%s)", current, now.Format("2006-01-02"))
+			}
+		}
+	}
+
+	return fmt.Sprintf("%d.%d.1", year, month), nil
+}
+
+// nextCalendarBuildVersion returns the YYYYMMDDNN build number following the current one: NN is incremented if the
+// current build number is from the same day, otherwise it starts again from 01. The new build number has to be greater
+// than the current one, so that the build numbers keep increasing even when switching to calendar versioning.
+func nextCalendarBuildVersion(current string, now time.Time) (string, error) {
+	date := now.Format("20060102")
+
+	n := 1
+	if len(current) == len(date)+2 && strings.HasPrefix(current, date) {
+		currentN, err := strconv.Atoi(strings.TrimPrefix(current, date))
+		if err == nil {
+			n = currentN + 1
+		}
+	}
+	if n > 99 {
+		return "", fmt.Errorf("the calendar build number of the day (%s) cannot be incremented above %s99", current, date)
+	}
+
+	next := fmt.Sprintf("%s%02d", date, n)
+
+	if parsedCurrent, err := strconv.ParseUint(current, 10, 64); err == nil {
+		parsedNext, err := strconv.ParseUint(next, 10, 64)
+		if err != nil {
+			return "", err
+		}
+		if parsedNext <= parsedCurrent {
+			return "", fmt.Errorf("the calendar build number (%s) would not be greater than the current build number (%s)", next, current)
+		}
+	}
+
+	return next, nil
+}
@@ -0,0 +1,107 @@
+package step
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bitrise-io/go-xcode/v2/autocodesign/projectmanager"
+)
+
+// versionMismatch is a build configuration of an embedded target whose version numbers differ from the main target's.
+type versionMismatch struct {
+	Expected targetVersions
+	Actual   targetVersions
+}
+
+// checkVersionConsistency compares the effective version numbers of every archivable target to the main target's, in
+// each build configuration. App Store Connect rejects the upload if an embedded target's version numbers differ from the
+// host app's, so it is better to find it out before the archive.
+func (u Updater) checkVersionConsistency(helper *projectmanager.ProjectHelper, config Config) error {
+	if config.VersionConsistencyCheck == "" || config.VersionConsistencyCheck == VersionConsistencyCheckOff {
+		return nil
+	}
+
+	u.logger.Println()
+	u.logger.Infof("Checking the version numbers of the archivable targets")
+
+	mainTarget := helper.MainTarget
+	configurations, err := targetConfigurations(helper, mainTarget.Name, config.Configuration)
+	if err != nil {
+		return err
+	}
+
+	var mismatches []versionMismatch
+	for _, configuration := range configurations {
+		expected, err := u.targetVersions(helper, config.Scheme, mainTarget.Name, configuration)
+		if err != nil {
+			return fmt.Errorf("failed to resolve the version numbers of the %s configuration of the %s target: %w", configuration, mainTarget.Name, err)
+		}
+
+		for _, target := range helper.ArchivableTargets() {
+			if target.Name == mainTarget.Name {
+				continue
+			}
+
+			if findBuildConfiguration(target.BuildConfigurationList, configuration) == nil {
+				u.logger.Debugf("The %s target has no %s configuration, skipping it", target.Name, configuration)
+				continue
+			}
+
+			actual, err := u.targetVersions(helper, config.Scheme, target.Name, configuration)
+			if err != nil {
+				return fmt.Errorf("failed to resolve the version numbers of the %s configuration of the %s target: %w", configuration, target.Name, err)
+			}
+
+			if actual.BuildVersion.Value != expected.BuildVersion.Value || actual.ShortVersion.Value != expected.ShortVersion.Value {
+				mismatches = append(mismatches, versionMismatch{Expected: expected, Actual: actual})
+			}
+		}
+	}
+
+	if len(mismatches) == 0 {
+		u.logger.Donef("The version numbers of the archivable targets match the %s target's", mainTarget.Name)
+		return nil
+	}
+
+	message := fmt.Sprintf("%d target configuration(s) have different version numbers than the %s target:\n%s", len(mismatches), mainTarget.Name, versionMismatchTable(mismatches))
+	if config.VersionConsistencyCheck == VersionConsistencyCheckWarn {
+		u.logger.Warnf("%s", message)
+		return nil
+	}
+
+	return errors.New(message)
+}
+
+func versionMismatchTable(mismatches []versionMismatch) string {
+	var table strings.Builder
+	writer := tabwriter.NewWriter(&table, 0, 0, 2, ' ', 0)
+
+	_, _ = fmt.Fprintln(writer, "Target\tConfiguration\tBuild number\tVersion number")
+	for _, mismatch := range mismatches {
+		_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n",
+			mismatch.Actual.Target,
+			mismatch.Actual.Configuration,
+			versionMismatchCell(mismatch.Actual.BuildVersion, mismatch.Expected.BuildVersion),
+			versionMismatchCell(mismatch.Actual.ShortVersion, mismatch.Expected.ShortVersion),
+		)
+	}
+	_ = writer.Flush()
+
+	return table.String()
+}
+
+func versionMismatchCell(actual, expected versionValue) string {
+	if actual.Value == expected.Value {
+		return displayVersion(actual.Value)
+	}
+	return fmt.Sprintf("%s (expected %s)", displayVersion(actual.Value), displayVersion(expected.Value))
+}
+
+func displayVersion(value string) string {
+	if value == "" {
+		return "<not set>"
+	}
+	return value
+}
@@ -0,0 +1,127 @@
+package step
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const versionBumpReportFileName = "version_bump_report.json"
+
+// conventionalCommitRegexp matches the header of a Conventional Commit message, like `feat(login): add SSO` or
+// `refactor!: drop iOS 14`.
+var conventionalCommitRegexp = regexp.MustCompile(`^([a-zA-Z]+)(?:\([^)]*\))?(!)?: `)
+
+// breakingChangeRegexp matches the BREAKING CHANGE footer of a Conventional Commit message.
+var breakingChangeRegexp = regexp.MustCompile(`(?m)^BREAKING[ -]CHANGE: `)
+
+// versionBumpReport is the version number bump decided from the Conventional Commit messages since the last release
+// tag, and the commits which caused it.
+type versionBumpReport struct {
+	Tag             string               `json:"tag"`
+	Bump            string               `json:"bump"`
+	PreviousVersion string               `json:"previous_version,omitempty"`
+	Version         string               `json:"version,omitempty"`
+	Commits         []conventionalCommit `json:"commits"`
+}
+
+// conventionalCommit is a commit whose message calls for a version number bump.
+type conventionalCommit struct {
+	SHA      string `json:"sha"`
+	Subject  string `json:"subject"`
+	Type     string `json:"type"`
+	Breaking bool   `json:"breaking"`
+	Bump     string `json:"bump"`
+}
+
+// conventionalCommitsBump scans the commits since the last release tag for Conventional Commit messages, and decides
+// which component of the version number to bump: major for breaking changes, minor for features and patch for fixes.
+func (u Updater) conventionalCommitsBump(config Config) (*versionBumpReport, error) {
+	repository := gitRepository{dir: filepath.Dir(config.ProjectPath)}
+	tag, _, err := repository.nearestTag(tagPatterns(config))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the last release tag: %w", err)
+	}
+
+	commits, err := repository.commitsSince(tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the commits since the %s tag: %w", tag, err)
+	}
+
+	report := versionBumpReport{Tag: tag, Bump: ShortVersionBumpNone, Commits: []conventionalCommit{}}
+	for _, commit := range commits {
+		parsed, ok := parseConventionalCommit(commit)
+		if !ok {
+			continue
+		}
+
+		report.Commits = append(report.Commits, parsed)
+		if bumpRank(parsed.Bump) > bumpRank(report.Bump) {
+			report.Bump = parsed.Bump
+		}
+	}
+
+	u.logger.Printf("%d commit(s) since the %s tag, %d of them call for a version number bump", len(commits), tag, len(report.Commits))
+	for _, commit := range report.Commits {
+		u.logger.Printf("- %s %s (%s)", shortSHA(commit.SHA), commit.Subject, commit.Bump)
+	}
+	if report.Bump == ShortVersionBumpNone {
+		u.logger.Printf("No features, fixes or breaking changes since the %s tag, the version number is not bumped", tag)
+	} else {
+		u.logger.Printf("Version number bump: %s", report.Bump)
+	}
+
+	return &report, nil
+}
+
+// parseConventionalCommit returns the version number bump the commit message calls for, if it is a Conventional Commit
+// message of a breaking change, a feature or a fix.
+func parseConventionalCommit(commit gitCommit) (conventionalCommit, bool) {
+	subject := strings.SplitN(commit.Message, "\n", 2)[0]
+
+	match := conventionalCommitRegexp.FindStringSubmatch(subject)
+	if match == nil {
+		return conventionalCommit{}, false
+	}
+
+	parsed := conventionalCommit{
+		SHA:      commit.SHA,
+		Subject:  subject,
+		Type:     strings.ToLower(match[1]),
+		Breaking: match[2] != "" || breakingChangeRegexp.MatchString(commit.Message),
+	}
+
+	switch {
+	case parsed.Breaking:
+		parsed.Bump = ShortVersionBumpMajor
+	case parsed.Type == "feat":
+		parsed.Bump = ShortVersionBumpMinor
+	case parsed.Type == "fix":
+		parsed.Bump = ShortVersionBumpPatch
+	default:
+		return conventionalCommit{}, false
+	}
+
+	return parsed, true
+}
+
+func bumpRank(bump string) int {
+	switch bump {
+	case ShortVersionBumpMajor:
+		return 3
+	case ShortVersionBumpMinor:
+		return 2
+	case ShortVersionBumpPatch:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func shortSHA(sha string) string {
+	if len(sha) > 7 {
+		return sha[:7]
+	}
+	return sha
+}
@@ -0,0 +1,82 @@
+package step
+
+import (
+	"fmt"
+	"strings"
+	"text/tabwriter"
+)
+
+// allTargetVersions returns the effective version numbers of every build configuration of the targets.
+func (u Updater) allTargetVersions(targets []bundleTarget, config Config) ([]targetVersions, error) {
+	var allVersions []targetVersions
+	for _, target := range targets {
+		configurations, err := targetConfigurations(target.helper, target.name, config.Configuration)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, configuration := range configurations {
+			versions, err := u.targetVersions(target.helper, config.Scheme, target.name, configuration)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resolve the version numbers of the %s configuration of the %s target: %w", configuration, target.name, err)
+			}
+
+			allVersions = append(allVersions, versions)
+		}
+	}
+	return allVersions, nil
+}
+
+// printDryRunChanges prints the unified diff of the planned file changes, and the old and new version numbers of each
+// build configuration of the targets.
+func (u Updater) printDryRunChanges(targets []bundleTarget, config Config, versionsBefore []targetVersions) error {
+	diff, err := u.files.diff()
+	if err != nil {
+		return err
+	}
+
+	u.logger.Println()
+	if diff == "" {
+		u.logger.Printf("Dry run: no file would be changed")
+	} else {
+		u.logger.Printf("Dry run: the following changes would be made")
+		u.logger.Printf("%s", diff)
+	}
+
+	// The planned changes are read back through the file store, so these are the version numbers after the update.
+	versionsAfter, err := u.allTargetVersions(targets, config)
+	if err != nil {
+		return err
+	}
+
+	u.logger.Println()
+	u.logger.Printf("Version numbers:")
+	u.logger.Printf("%s", versionChangeTable(versionsBefore, versionsAfter))
+
+	return nil
+}
+
+func versionChangeTable(before, after []targetVersions) string {
+	var table strings.Builder
+	writer := tabwriter.NewWriter(&table, 0, 0, 2, ' ', 0)
+
+	_, _ = fmt.Fprintln(writer, "Target\tConfiguration\tBuild number\tVersion number")
+	for i, versions := range after {
+		_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\t%s\n",
+			versions.Target,
+			versions.Configuration,
+			versionChangeCell(before[i].BuildVersion, versions.BuildVersion),
+			versionChangeCell(before[i].ShortVersion, versions.ShortVersion),
+		)
+	}
+	_ = writer.Flush()
+
+	return table.String()
+}
+
+func versionChangeCell(before, after versionValue) string {
+	if before.Value == after.Value {
+		return displayVersion(after.Value)
+	}
+	return fmt.Sprintf("%s -> %s", displayVersion(before.Value), displayVersion(after.Value))
+}
@@ -0,0 +1,317 @@
+package step
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/bitrise-io/go-xcode/v2/autocodesign/projectmanager"
+	"github.com/bitrise-io/go-xcode/xcodeproject/serialized"
+	"github.com/bitrise-io/go-xcode/xcodeproject/xcodeproj"
+	"github.com/bitrise-io/go-xcode/xcodeproject/xcworkspace"
+)
+
+// embeddedProductTypes are the product types which carry their own version numbers when they are embedded into the app
+// bundle through a Copy Files build phase. Frameworks and libraries are versioned independently of the app.
+var embeddedProductTypes = []string{
+	"com.apple.product-type.application",
+	"com.apple.product-type.app-extension",
+	"com.apple.product-type.extensionkit-extension",
+	"com.apple.product-type.watchkit2-extension",
+	"com.apple.product-type.xpc-service",
+	"com.apple.product-type.tool",
+}
+
+// bundleTarget is a target of the app bundle, together with the project containing it.
+type bundleTarget struct {
+	helper *projectmanager.ProjectHelper
+	name   string
+}
+
+// embeddedTargetFinder collects the targets embedded into a target, either through target dependencies or through
+// Copy Files (Embed) build phases. The embedded products can be built by targets of other projects of the workspace.
+type embeddedTargetFinder struct {
+	logger                log.Logger
+	projects              map[string]*projectmanager.ProjectHelper
+	workspaceProjectPaths []string
+}
+
+func newEmbeddedTargetFinder(logger log.Logger, helper *projectmanager.ProjectHelper, projectPath string) (*embeddedTargetFinder, error) {
+	finder := &embeddedTargetFinder{
+		logger:   logger,
+		projects: map[string]*projectmanager.ProjectHelper{helper.XcProj.Path: helper},
+	}
+
+	if xcworkspace.IsWorkspace(projectPath) {
+		workspace, err := xcworkspace.Open(projectPath)
+		if err != nil {
+			return nil, err
+		}
+
+		finder.workspaceProjectPaths, err = workspace.ProjectFileLocations()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return finder, nil
+}
+
+// embeddedTargets returns the targets embedded into the given target, recursively.
+func (f *embeddedTargetFinder) embeddedTargets(helper *projectmanager.ProjectHelper, target xcodeproj.Target) ([]bundleTarget, error) {
+	visited := map[string]bool{helper.XcProj.Path + ":" + target.ID: true}
+	return f.collectEmbeddedTargets(helper, target, visited)
+}
+
+func (f *embeddedTargetFinder) collectEmbeddedTargets(helper *projectmanager.ProjectHelper, target xcodeproj.Target, visited map[string]bool) ([]bundleTarget, error) {
+	var embedded []bundleTarget
+	add := func(helper *projectmanager.ProjectHelper, child xcodeproj.Target, include bool) error {
+		key := helper.XcProj.Path + ":" + child.ID
+		if visited[key] {
+			return nil
+		}
+		visited[key] = true
+
+		if include {
+			embedded = append(embedded, bundleTarget{helper: helper, name: child.Name})
+		}
+
+		children, err := f.collectEmbeddedTargets(helper, child, visited)
+		if err != nil {
+			return err
+		}
+		embedded = append(embedded, children...)
+
+		return nil
+	}
+
+	for _, dependency := range target.Dependencies {
+		child, ok := helper.XcProj.Proj.Target(dependency.TargetID)
+		if !ok {
+			continue
+		}
+
+		if err := add(helper, child, child.IsExecutableProduct()); err != nil {
+			return nil, err
+		}
+	}
+
+	products, err := f.copiedProductTargets(helper, target)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, product := range products {
+		include := isEmbeddedProductType(product.target.ProductType)
+		if !include {
+			f.logger.Debugf("Skipping %s, embedded into %s: %s products are versioned independently", product.target.Name, target.Name, product.target.ProductType)
+		}
+
+		if err := add(product.helper, product.target, include); err != nil {
+			return nil, err
+		}
+	}
+
+	return embedded, nil
+}
+
+type copiedProduct struct {
+	helper *projectmanager.ProjectHelper
+	target xcodeproj.Target
+}
+
+// copiedProductTargets returns the targets building the products copied by the Copy Files build phases of the target.
+func (f *embeddedTargetFinder) copiedProductTargets(helper *projectmanager.ProjectHelper, target xcodeproj.Target) ([]copiedProduct, error) {
+	objects, err := helper.XcProj.RawProj.Object("objects")
+	if err != nil {
+		return nil, err
+	}
+
+	rawTarget, err := objects.Object(target.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	phaseIDs, err := rawTarget.StringSlice("buildPhases")
+	if err != nil {
+		if serialized.IsKeyNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var products []copiedProduct
+	for _, phaseID := range phaseIDs {
+		phase, err := objects.Object(phaseID)
+		if err != nil {
+			return nil, err
+		}
+
+		if isa, err := phase.String("isa"); err != nil || isa != "PBXCopyFilesBuildPhase" {
+			continue
+		}
+
+		fileIDs, err := phase.StringSlice("files")
+		if err != nil {
+			continue
+		}
+
+		for _, fileID := range fileIDs {
+			buildFile, err := objects.Object(fileID)
+			if err != nil {
+				return nil, err
+			}
+
+			// Swift packages are referenced by productRef instead of fileRef, these are not built by a target.
+			referenceID, err := buildFile.String("fileRef")
+			if err != nil {
+				continue
+			}
+
+			product, ok, err := f.productTarget(helper, objects, referenceID)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				f.logger.Debugf("No target found for the %s product copied by %s", referenceID, target.Name)
+				continue
+			}
+
+			products = append(products, product)
+		}
+	}
+
+	return products, nil
+}
+
+// productTarget returns the target building the referenced product. The product can be built by a target of the same
+// project, by a target of a referenced sub-project, or implicitly by a target of another project in the workspace.
+func (f *embeddedTargetFinder) productTarget(helper *projectmanager.ProjectHelper, objects serialized.Object, referenceID string) (copiedProduct, bool, error) {
+	reference, err := objects.Object(referenceID)
+	if err != nil {
+		return copiedProduct{}, false, err
+	}
+
+	isa, err := reference.String("isa")
+	if err != nil {
+		return copiedProduct{}, false, err
+	}
+
+	switch isa {
+	case "PBXReferenceProxy":
+		remoteRefID, err := reference.String("remoteRef")
+		if err != nil {
+			return copiedProduct{}, false, err
+		}
+
+		proxy, err := objects.Object(remoteRefID)
+		if err != nil {
+			return copiedProduct{}, false, err
+		}
+
+		portalID, err := proxy.String("containerPortal")
+		if err != nil {
+			return copiedProduct{}, false, err
+		}
+
+		remoteProductID, err := proxy.String("remoteGlobalIDString")
+		if err != nil {
+			return copiedProduct{}, false, err
+		}
+
+		projectPath, err := newBuildSettingsResolver(helper.XcProj, nil, f.logger).fileReferencePath(objects, portalID)
+		if err != nil {
+			return copiedProduct{}, false, err
+		}
+
+		subProject, err := f.project(projectPath)
+		if err != nil {
+			return copiedProduct{}, false, fmt.Errorf("failed to open the referenced project (%s): %w", projectPath, err)
+		}
+
+		target, ok := targetByProductReference(subProject.XcProj, remoteProductID)
+		return copiedProduct{helper: subProject, target: target}, ok, nil
+	case "PBXFileReference":
+		if target, ok := targetByProductReference(helper.XcProj, referenceID); ok {
+			return copiedProduct{helper: helper, target: target}, true, nil
+		}
+
+		sourceTree, err := reference.String("sourceTree")
+		if err != nil || sourceTree != "BUILT_PRODUCTS_DIR" {
+			return copiedProduct{}, false, nil
+		}
+
+		productPath, err := reference.String("path")
+		if err != nil {
+			return copiedProduct{}, false, nil
+		}
+
+		for _, projectPath := range f.workspaceProjectPaths {
+			project, err := f.project(projectPath)
+			if err != nil {
+				return copiedProduct{}, false, fmt.Errorf("failed to open the workspace project (%s): %w", projectPath, err)
+			}
+
+			for _, target := range project.XcProj.Proj.Targets {
+				if target.ProductReference.Path == productPath {
+					return copiedProduct{helper: project, target: target}, true, nil
+				}
+			}
+		}
+	}
+
+	return copiedProduct{}, false, nil
+}
+
+// project opens the project at the given path, reusing the already opened projects so that their changes are saved
+// only once.
+func (f *embeddedTargetFinder) project(pth string) (*projectmanager.ProjectHelper, error) {
+	absPth, err := filepath.Abs(pth)
+	if err != nil {
+		return nil, err
+	}
+
+	if helper, ok := f.projects[absPth]; ok {
+		return helper, nil
+	}
+
+	project, err := xcodeproj.Open(absPth)
+	if err != nil {
+		return nil, err
+	}
+
+	helper := &projectmanager.ProjectHelper{XcProj: project}
+	f.projects[absPth] = helper
+
+	return helper, nil
+}
+
+func targetByProductReference(project xcodeproj.XcodeProj, productReferenceID string) (xcodeproj.Target, bool) {
+	objects, err := project.RawProj.Object("objects")
+	if err != nil {
+		return xcodeproj.Target{}, false
+	}
+
+	for _, target := range project.Proj.Targets {
+		rawTarget, err := objects.Object(target.ID)
+		if err != nil {
+			continue
+		}
+
+		if id, err := rawTarget.String("productReference"); err == nil && id == productReferenceID {
+			return target, true
+		}
+	}
+
+	return xcodeproj.Target{}, false
+}
+
+func isEmbeddedProductType(productType string) bool {
+	for _, embeddedType := range embeddedProductTypes {
+		if productType == embeddedType || strings.HasPrefix(productType, embeddedType+".") {
+			return true
+		}
+	}
+	return false
+}
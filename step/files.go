@@ -0,0 +1,168 @@
+package step
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	plist "github.com/bitrise-io/go-plist"
+	"github.com/bitrise-io/go-xcode/xcodeproject/serialized"
+	"github.com/bitrise-io/go-xcode/xcodeproject/xcodeproj"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// fileStore reads and writes the files of the project. In dry-run mode the writes are only recorded, and the later reads
+// return the recorded content, so that the planned changes build on each other without touching the working tree.
+//
+// A nil fileStore reads and writes the files directly.
+type fileStore struct {
+	dryRun   bool
+	original map[string][]byte
+	planned  map[string][]byte
+	paths    []string
+}
+
+func newFileStore(dryRun bool) *fileStore {
+	return &fileStore{
+		dryRun:   dryRun,
+		original: map[string][]byte{},
+		planned:  map[string][]byte{},
+	}
+}
+
+func (s *fileStore) isDryRun() bool {
+	return s != nil && s.dryRun
+}
+
+func (s *fileStore) readFile(pth string) ([]byte, error) {
+	if s.isDryRun() {
+		if content, ok := s.planned[pth]; ok {
+			return content, nil
+		}
+	}
+	return os.ReadFile(pth)
+}
+
+func (s *fileStore) writeFile(pth string, content []byte) error {
+	if !s.isDryRun() {
+		return os.WriteFile(pth, content, 0644)
+	}
+
+	if _, ok := s.original[pth]; !ok {
+		original, err := os.ReadFile(pth)
+		if err != nil {
+			return err
+		}
+		s.original[pth] = original
+		s.paths = append(s.paths, pth)
+	}
+	s.planned[pth] = content
+
+	return nil
+}
+
+func (s *fileStore) readPlist(pth string) (serialized.Object, int, error) {
+	if !s.isDryRun() {
+		return xcodeproj.ReadPlistFile(pth)
+	}
+
+	content, err := s.readFile(pth)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var object serialized.Object
+	format, err := plist.Unmarshal(content, &object)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return object, format, nil
+}
+
+func (s *fileStore) writePlist(pth string, object serialized.Object, format int) error {
+	if !s.isDryRun() {
+		return xcodeproj.WritePlistFile(pth, object, format)
+	}
+
+	content, err := plist.Marshal(object, format)
+	if err != nil {
+		return err
+	}
+
+	return s.writeFile(pth, content)
+}
+
+// saveProject saves the project.pbxproj file. In dry-run mode the project is saved into a temporary directory to get
+// its new content, keeping the original formatting the same way as a real save would.
+func (s *fileStore) saveProject(project xcodeproj.XcodeProj) error {
+	if !s.isDryRun() {
+		return project.Save()
+	}
+
+	dir, err := os.MkdirTemp("", "dry-run")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.RemoveAll(dir)
+	}()
+
+	pbxprojPath := filepath.Join(project.Path, "project.pbxproj")
+
+	project.Path = filepath.Join(dir, filepath.Base(project.Path))
+	if err := os.MkdirAll(project.Path, 0755); err != nil {
+		return err
+	}
+
+	if err := project.Save(); err != nil {
+		return err
+	}
+
+	content, err := os.ReadFile(filepath.Join(project.Path, "project.pbxproj"))
+	if err != nil {
+		return err
+	}
+
+	return s.writeFile(pbxprojPath, content)
+}
+
+// diff returns the unified diff of the planned changes of every file.
+func (s *fileStore) diff() (string, error) {
+	var diff string
+	for _, pth := range s.paths {
+		fileDiff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        difflib.SplitLines(string(s.original[pth])),
+			B:        difflib.SplitLines(string(s.planned[pth])),
+			FromFile: pth,
+			ToFile:   pth,
+			Context:  3,
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to diff %s: %w", pth, err)
+		}
+		diff += fileDiff
+	}
+	return diff, nil
+}
+
+// writeJSONFile writes the value as indented JSON into a new temporary directory, outside of the working tree.
+func writeJSONFile(dirPattern, fileName string, value interface{}) (string, error) {
+	content, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	dir, err := os.MkdirTemp("", dirPattern)
+	if err != nil {
+		return "", err
+	}
+
+	pth := filepath.Join(dir, fileName)
+	if err := os.WriteFile(pth, content, 0644); err != nil {
+		return "", err
+	}
+
+	return pth, nil
+}
@@ -0,0 +1,30 @@
+package step
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_fileStore_dryRun(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), "Config.xcconfig")
+	require.NoError(t, os.WriteFile(pth, []byte("MARKETING_VERSION = 1.0\nCURRENT_PROJECT_VERSION = 1\n"), 0644))
+
+	files := newFileStore(true)
+	require.NoError(t, files.writeFile(pth, []byte("MARKETING_VERSION = 2.0\nCURRENT_PROJECT_VERSION = 1\n")))
+	require.NoError(t, files.writeFile(pth, []byte("MARKETING_VERSION = 2.0\nCURRENT_PROJECT_VERSION = 42\n")))
+
+	content, err := files.readFile(pth)
+	require.NoError(t, err)
+	require.Equal(t, "MARKETING_VERSION = 2.0\nCURRENT_PROJECT_VERSION = 42\n", string(content))
+
+	content, err = os.ReadFile(pth)
+	require.NoError(t, err)
+	require.Equal(t, "MARKETING_VERSION = 1.0\nCURRENT_PROJECT_VERSION = 1\n", string(content))
+
+	diff, err := files.diff()
+	require.NoError(t, err)
+	require.Equal(t, "--- "+pth+"\n+++ "+pth+"\n@@ -1,3 +1,3 @@\n-MARKETING_VERSION = 1.0\n-CURRENT_PROJECT_VERSION = 1\n+MARKETING_VERSION = 2.0\n+CURRENT_PROJECT_VERSION = 42\n \n", diff)
+}
@@ -0,0 +1,163 @@
+package step
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxBundleVersionLength is the maximum length of the CFBundleVersion App Store Connect accepts.
+	maxBundleVersionLength = 18
+	// maxBundleVersionComponents is the maximum number of period-separated integers of the CFBundleVersion.
+	maxBundleVersionComponents = 3
+	// maxBundleVersionComponentValue is the largest value of a CFBundleVersion component, a signed 32-bit integer.
+	maxBundleVersionComponentValue = 1<<31 - 1
+)
+
+var defaultFormulaDigits = []int{2, 2, 2}
+
+// formulaBuildVersion combines the version number with the CI build number into the build number: 1.4.2 and build 37
+// become 1040237 in the packed format (with 2 digits for the minor, the patch and the build), and 1.4 and build 37 become
+// 1.4.37 in the dotted format. The build number has to be greater than the project's current build number.
+func (u Updater) formulaBuildVersion(targets []bundleTarget, config Config, ciBuildVersion string) (string, error) {
+	allVersions, err := u.allTargetVersions(targets, config)
+	if err != nil {
+		return "", err
+	}
+
+	shortVersion := config.BuildShortVersionString
+	if shortVersion == "" {
+		shortVersion, err = currentVersion(allVersions, "version number", func(versions targetVersions) versionValue {
+			return versions.ShortVersion
+		})
+		if err != nil {
+			return "", fmt.Errorf("the build number formula cannot be applied: %w", err)
+		}
+	}
+
+	var buildVersion string
+	if config.BuildVersionFormula == BuildVersionFormulaDotted {
+		buildVersion, err = dottedFormulaBuildVersion(shortVersion, ciBuildVersion)
+	} else {
+		digits := config.BuildVersionFormulaDigits
+		if len(digits) == 0 {
+			digits = defaultFormulaDigits
+		}
+		buildVersion, err = packedFormulaBuildVersion(shortVersion, ciBuildVersion, digits)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if len(buildVersion) > maxBundleVersionLength {
+		return "", fmt.Errorf("the build number (%s) is longer than %d characters", buildVersion, maxBundleVersionLength)
+	}
+
+	u.logger.Printf("Build number from the version number (%s) and the build number (%s): %s", shortVersion, ciBuildVersion, buildVersion)
+
+	current, err := currentVersion(allVersions, "build number", func(versions targetVersions) versionValue {
+		return versions.BuildVersion
+	})
+	if err != nil {
+		u.logger.Warnf("The build number is not compared to the current one: %s", err)
+		return buildVersion, nil
+	}
+
+	comparison, err := compareVersions(buildVersion, current)
+	if err != nil {
+		u.logger.Debugf("The build number is not compared to the current one (%s): %s", displayVersion(current), err)
+		return buildVersion, nil
+	}
+	if comparison <= 0 {
+		return "", fmt.Errorf("the build number (%s) is not greater than the current build number (%s), every upload needs a unique, increasing build number", buildVersion, current)
+	}
+
+	return buildVersion, nil
+}
+
+// packedFormulaBuildVersion concatenates the components of the version number and the CI build number, each padded to
+// its number of digits. The major component is not padded.
+func packedFormulaBuildVersion(shortVersion, ciBuildVersion string, digits []int) (string, error) {
+	components, err := formulaComponents(shortVersion, ciBuildVersion)
+	if err != nil {
+		return "", err
+	}
+	if len(digits) != 3 {
+		return "", fmt.Errorf("the build number formula needs the number of digits of the minor, the patch and the build number, got %d value(s)", len(digits))
+	}
+
+	names := []string{"minor", "patch", "build"}
+	packed := strconv.FormatUint(components[0], 10)
+	for i, component := range components[1:] {
+		if len(strconv.FormatUint(component, 10)) > digits[i] {
+			return "", fmt.Errorf("the %s component (%d) does not fit into %d digit(s) of the build number formula", names[i], component, digits[i])
+		}
+		packed += fmt.Sprintf("%0*d", digits[i], component)
+	}
+
+	// A 0 major component would be a leading zero.
+	packed = strings.TrimLeft(packed, "0")
+	if packed == "" {
+		return "0", nil
+	}
+
+	if value, err := strconv.ParseUint(packed, 10, 64); err != nil || value > maxBundleVersionComponentValue {
+		return "", fmt.Errorf("the build number (%s) is greater than %d, the largest CFBundleVersion component: use fewer digits in the build number formula (%s)", packed, maxBundleVersionComponentValue, formatFormulaDigits(digits))
+	}
+
+	return packed, nil
+}
+
+func formatFormulaDigits(digits []int) string {
+	var values []string
+	for _, digit := range digits {
+		values = append(values, strconv.Itoa(digit))
+	}
+	return strings.Join(values, ",")
+}
+
+// dottedFormulaBuildVersion appends the CI build number to the version number as a new component.
+func dottedFormulaBuildVersion(shortVersion, ciBuildVersion string) (string, error) {
+	if _, err := formulaComponents(shortVersion, ciBuildVersion); err != nil {
+		return "", err
+	}
+
+	buildVersion := shortVersion + "." + ciBuildVersion
+	if count := strings.Count(buildVersion, ".") + 1; count > maxBundleVersionComponents {
+		return "", fmt.Errorf("the build number (%s) has %d components, but the CFBundleVersion can have at most %d: use the packed build number formula or a shorter version number", buildVersion, count, maxBundleVersionComponents)
+	}
+
+	return buildVersion, nil
+}
+
+// formulaComponents returns the major, minor and patch components of the version number (0 if missing), and the CI
+// build number.
+func formulaComponents(shortVersion, ciBuildVersion string) ([]uint64, error) {
+	components, err := parseVersionComponents(shortVersion)
+	if err != nil || len(components) > 3 {
+		return nil, fmt.Errorf("the version number (%s) is not one to three period-separated integers", displayVersion(shortVersion))
+	}
+	for len(components) < 3 {
+		components = append(components, 0)
+	}
+
+	build, err := strconv.ParseUint(ciBuildVersion, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("the build number (%s) of the build number formula is not a non-negative integer", displayVersion(ciBuildVersion))
+	}
+
+	return append(components, build), nil
+}
+
+func parseFormulaDigits(value string) ([]int, error) {
+	var digits []int
+	for _, item := range parseList(value) {
+		digit, err := strconv.Atoi(item)
+		if err != nil || digit < 1 {
+			return nil, fmt.Errorf("invalid number of digits (%s) in the build number formula digits (%s)", item, value)
+		}
+		digits = append(digits, digit)
+	}
+	return digits, nil
+}
@@ -0,0 +1,139 @@
+package step
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bitrise-io/go-utils/v2/command"
+	"github.com/bitrise-io/go-utils/v2/env"
+)
+
+// gitRepository reads the history of the local git repository, without accessing the network.
+type gitRepository struct {
+	dir string
+}
+
+func (r gitRepository) run(args ...string) (string, error) {
+	cmd := command.NewFactory(env.NewRepository()).Create("git", args, &command.Opts{Dir: r.dir})
+	output, err := cmd.RunAndReturnTrimmedCombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("%s failed: %w: %s", cmd.PrintableCommandArgs(), err, output)
+	}
+	return output, nil
+}
+
+func (r gitRepository) isShallow() (bool, error) {
+	output, err := r.run("rev-parse", "--is-shallow-repository")
+	if err != nil {
+		return false, err
+	}
+	return output == "true", nil
+}
+
+// commitCount returns the number of commits reachable from HEAD, or only the ones not reachable from the base ref if
+// it is given.
+func (r gitRepository) commitCount(baseRef string) (int64, error) {
+	shallow, err := r.isShallow()
+	if err != nil {
+		return 0, err
+	}
+	if shallow {
+		return 0, fmt.Errorf("the repository (%s) is a shallow clone, so the commit count would be wrong: fetch the full history (for example by setting the clone depth of the Git Clone step to 0) or use the commit timestamp as the build number", r.dir)
+	}
+
+	revision := "HEAD"
+	if baseRef != "" {
+		if _, err := r.run("rev-parse", "--verify", "--quiet", baseRef+"^{commit}"); err != nil {
+			return 0, fmt.Errorf("the base ref (%s) is not found in the local repository (%s)", baseRef, r.dir)
+		}
+		revision = baseRef + "..HEAD"
+	}
+
+	output, err := r.run("rev-list", "--count", revision)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseInt(output, 10, 64)
+}
+
+// commitTime returns the committer date of HEAD.
+func (r gitRepository) commitTime() (time.Time, error) {
+	output, err := r.run("show", "--no-patch", "--format=%ct", "HEAD")
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	timestamp, err := strconv.ParseInt(strings.TrimSpace(output), 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid commit timestamp (%s): %w", output, err)
+	}
+
+	return time.Unix(timestamp, 0), nil
+}
+
+// nearestTag returns the nearest tag reachable from HEAD which matches one of the glob patterns, and the number of
+// commits HEAD is ahead of it.
+func (r gitRepository) nearestTag(patterns []string) (string, int64, error) {
+	args := []string{"describe", "--tags", "--abbrev=0"}
+	for _, pattern := range patterns {
+		args = append(args, "--match", pattern)
+	}
+	args = append(args, "HEAD")
+
+	tag, err := r.run(args...)
+	if err != nil {
+		message := fmt.Sprintf("no tag matching %s is reachable from HEAD", strings.Join(patterns, ", "))
+		if shallow, shallowErr := r.isShallow(); shallowErr == nil && shallow {
+			message += fmt.Sprintf(", the repository (%s) is a shallow clone: fetch the full history and the tags (for example by setting the clone depth of the Git Clone step to 0)", r.dir)
+		}
+		return "", 0, fmt.Errorf("%s: %w", message, err)
+	}
+
+	output, err := r.run("rev-list", "--count", tag+"..HEAD")
+	if err != nil {
+		return "", 0, err
+	}
+
+	ahead, err := strconv.ParseInt(output, 10, 64)
+	if err != nil {
+		return "", 0, err
+	}
+
+	return tag, ahead, nil
+}
+
+// gitCommit is a commit of the local git repository.
+type gitCommit struct {
+	SHA     string
+	Message string
+}
+
+// commitsSince returns the commits reachable from HEAD but not from the given revision, the newest first.
+func (r gitRepository) commitsSince(revision string) ([]gitCommit, error) {
+	// The fields are separated by the unit separator and the commits by the record separator, which do not appear in
+	// commit messages.
+	output, err := r.run("log", "--format=%H%x1f%B%x1e", revision+"..HEAD")
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []gitCommit
+	for _, record := range strings.Split(output, "\x1e") {
+		record = strings.TrimSpace(record)
+		if record == "" {
+			continue
+		}
+
+		fields := strings.SplitN(record, "\x1f", 2)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("invalid git log record: %s", record)
+		}
+
+		commits = append(commits, gitCommit{SHA: fields[0], Message: strings.TrimSpace(fields[1])})
+	}
+
+	return commits, nil
+}
@@ -0,0 +1,87 @@
+package step
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var defaultTagPatterns = []string{"v*", "release/*"}
+
+// tagVersionRegexp matches the semantic version at the end of a tag name, like v1.4.2, release/1.4.2 or
+// v1.4.2-beta.1+build.5.
+var tagVersionRegexp = regexp.MustCompile(`(?:^|[^0-9.])(\d+(?:\.\d+){0,2})(?:-([0-9A-Za-z.-]+))?(?:\+([0-9A-Za-z.-]+))?$`)
+
+// tagVersion is the semantic version parsed from a tag name.
+type tagVersion struct {
+	Version    string
+	PreRelease string
+	Metadata   string
+}
+
+// gitTagShortVersion returns the version number parsed from the nearest tag reachable from HEAD which matches the tag
+// patterns.
+func (u Updater) gitTagShortVersion(config Config) (string, error) {
+	repository := gitRepository{dir: filepath.Dir(config.ProjectPath)}
+	tag, ahead, err := repository.nearestTag(tagPatterns(config))
+	if err != nil {
+		return "", fmt.Errorf("failed to find the release tag: %w", err)
+	}
+
+	parsed, err := parseTagVersion(tag)
+	if err != nil {
+		return "", err
+	}
+
+	if parsed.PreRelease != "" || parsed.Metadata != "" {
+		if !config.GitTagStripPreRelease {
+			return "", fmt.Errorf("the version of the %s tag has pre-release or build metadata, which App Store Connect rejects: enable stripping them or use another tag", tag)
+		}
+		u.logger.Printf("Stripping the pre-release and build metadata of the %s tag", tag)
+	}
+
+	version := parsed.Version
+	u.logger.Printf("Version number from the %s tag: %s", tag, version)
+
+	if ahead > 0 && config.GitTagBumpPatch {
+		version, err = bumpVersion(version, ShortVersionBumpPatch)
+		if err != nil {
+			return "", err
+		}
+		u.logger.Printf("HEAD is %d commit(s) ahead of the %s tag, bumping the patch component: %s", ahead, tag, version)
+	}
+
+	return version, nil
+}
+
+func tagPatterns(config Config) []string {
+	if len(config.GitTagPatterns) == 0 {
+		return defaultTagPatterns
+	}
+	return config.GitTagPatterns
+}
+
+func parseTagVersion(tag string) (tagVersion, error) {
+	match := tagVersionRegexp.FindStringSubmatch(tag)
+	if match == nil {
+		return tagVersion{}, fmt.Errorf("the %s tag does not end with a version number of one to three period-separated integers", tag)
+	}
+
+	// Leading zeros are dropped, the same way App Store Connect compares the components.
+	var components []string
+	for _, component := range strings.Split(match[1], ".") {
+		number, err := strconv.ParseUint(component, 10, 63)
+		if err != nil {
+			return tagVersion{}, fmt.Errorf("invalid version number in the %s tag: %w", tag, err)
+		}
+		components = append(components, strconv.FormatUint(number, 10))
+	}
+
+	return tagVersion{
+		Version:    strings.Join(components, "."),
+		PreRelease: match[2],
+		Metadata:   match[3],
+	}, nil
+}
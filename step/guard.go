@@ -0,0 +1,70 @@
+package step
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// checkBuildVersionDecrease refuses a build number lower than the current build number of a target, or lower than the
+// minimum build number. The build numbers are compared as period-separated integers, the way App Store Connect orders
+// them, so 10 is greater than 9 and 1.10 is greater than 1.9.
+func (u Updater) checkBuildVersionDecrease(targets []bundleTarget, config Config) error {
+	allVersions, err := u.allTargetVersions(targets, config)
+	if err != nil {
+		return err
+	}
+
+	var decreases []string
+	for _, versions := range allVersions {
+		buildVersion := configurationVersions(config, versions.Configuration).BuildVersion
+		current := versions.BuildVersion.Value
+
+		comparison, err := compareVersions(buildVersion, current)
+		if err != nil {
+			u.logger.Debugf("The build number of the %s configuration of the %s target (%s) is not compared to the current one (%s): %s", versions.Configuration, versions.Target, buildVersion, displayVersion(current), err)
+			continue
+		}
+		if comparison < 0 {
+			decreases = append(decreases, fmt.Sprintf("- %s (%s): %s is lower than the current build number %s (%s)", versions.Target, versions.Configuration, buildVersion, current, versions.BuildVersion.Location))
+		}
+	}
+
+	if config.MinimumBuildVersion != "" {
+		for _, configuration := range uniqueConfigurations(allVersions) {
+			buildVersion := configurationVersions(config, configuration).BuildVersion
+
+			comparison, err := compareVersions(buildVersion, config.MinimumBuildVersion)
+			if err != nil {
+				return fmt.Errorf("the build number (%s) cannot be compared to the minimum build number (%s): %w", buildVersion, config.MinimumBuildVersion, err)
+			}
+			if comparison < 0 {
+				decreases = append(decreases, fmt.Sprintf("- %s: %s is lower than the minimum build number %s", configuration, buildVersion, config.MinimumBuildVersion))
+			}
+		}
+	}
+
+	if len(decreases) == 0 {
+		return nil
+	}
+
+	message := fmt.Sprintf("the build number would go backwards:\n%s", strings.Join(decreases, "\n"))
+	if config.ForceBuildVersionDecrease {
+		u.logger.Warnf("Forcing the build number decrease, %s", message)
+		return nil
+	}
+
+	return errors.New(message + "\nApp Store Connect rejects a build number lower than the previous upload's, set force_build_version_decrease to true to allow it anyway")
+}
+
+func uniqueConfigurations(allVersions []targetVersions) []string {
+	var configurations []string
+	seen := map[string]bool{}
+	for _, versions := range allVersions {
+		if !seen[versions.Configuration] {
+			seen[versions.Configuration] = true
+			configurations = append(configurations, versions.Configuration)
+		}
+	}
+	return configurations
+}
@@ -0,0 +1,230 @@
+package step
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/pathutil"
+	"github.com/bitrise-io/go-utils/v2/command"
+	"github.com/bitrise-io/go-utils/v2/env"
+	"github.com/bitrise-io/go-xcode/v2/autocodesign/projectmanager"
+)
+
+func (u Updater) updateVersionNumbersInInfoPlist(helper *projectmanager.ProjectHelper, schemeName, targetName, configuration string, bundleVersion, shortVersion string, options buildSettingUpdateOptions) ([]string, error) {
+	infoPlistPath, err := u.infoPlistPath(helper, schemeName, targetName, configuration)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.updateInfoPlist(helper, infoPlistPath, targetName, configuration, infoPlistVersions(bundleVersion, shortVersion), options)
+}
+
+// updateVersionNumbersInHybridInfoPlist updates a target which generates its Info.plist, but also has a static one.
+// Xcode merges the two, and the keys of the static file win over the generated ones. So the version build settings are
+// always updated, and the static file is updated too if it defines the version keys itself.
+func (u Updater) updateVersionNumbersInHybridInfoPlist(helper *projectmanager.ProjectHelper, infoPlistPath, targetName, configuration string, bundleVersion, shortVersion string, options buildSettingUpdateOptions) ([]string, error) {
+	infoPlist, _, err := u.files.readPlist(infoPlistPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var overriddenVersions []buildSettingValue
+	for _, version := range infoPlistVersions(bundleVersion, shortVersion) {
+		if _, ok := infoPlist[version.Key]; ok {
+			u.logger.Printf("%s is defined in the static Info.plist, which overrides the generated value, updating both of them", version.Key)
+			overriddenVersions = append(overriddenVersions, version)
+		} else {
+			u.logger.Printf("%s is not defined in the static Info.plist, the generated value takes effect", version.Key)
+		}
+	}
+
+	updatedFiles, err := u.updateVersionNumbersInProject(helper, targetName, configuration, bundleVersion, shortVersion, options)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(overriddenVersions) == 0 {
+		return updatedFiles, nil
+	}
+
+	updatedPlistFiles, err := u.updateInfoPlist(helper, infoPlistPath, targetName, configuration, overriddenVersions, options)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(updatedFiles, updatedPlistFiles...), nil
+}
+
+// updateInfoPlist updates the version keys of the Info.plist and returns the paths of the updated files. Keys already
+// holding the new value are left alone, so a plist shared by multiple configurations is only written once.
+func (u Updater) updateInfoPlist(helper *projectmanager.ProjectHelper, infoPlistPath, targetName, configuration string, versions []buildSettingValue, options buildSettingUpdateOptions) ([]string, error) {
+	u.logger.Printf("Updating Info.plist at %s", infoPlistPath)
+
+	infoPlist, format, err := u.files.readPlist(infoPlistPath)
+	if err != nil {
+		return nil, err
+	}
+
+	// The Info.plist values can reference build settings, like `$(CURRENT_PROJECT_VERSION)`. Overwriting these with a
+	// literal would break the project's single source of truth, so the referenced build settings are updated instead.
+	var referencedSettings []buildSettingValue
+	plistChanged := false
+	for _, version := range versions {
+		oldValue := infoPlist[version.Key]
+
+		if setting, ok := buildSettingReference(oldValue); ok {
+			u.logger.Printf("%s references the %s build setting, updating the build setting instead", version.Key, setting)
+			referencedSettings = append(referencedSettings, buildSettingValue{Key: setting, Value: version.Value})
+			continue
+		}
+
+		if oldValue == version.Value {
+			continue
+		}
+
+		if value, ok := oldValue.(string); ok && strings.Contains(value, "$") {
+			u.logger.Warnf("%s (%s) is not a single build setting reference, overwriting it with the new value", version.Key, value)
+		}
+
+		infoPlist[version.Key] = version.Value
+		plistChanged = true
+
+		u.logger.Debugf("%s %s -> %s", version.Key, oldValue, version.Value)
+	}
+
+	var updatedFiles []string
+	if plistChanged {
+		err = u.files.writePlist(infoPlistPath, infoPlist, format)
+		if err != nil {
+			return nil, err
+		}
+		updatedFiles = append(updatedFiles, infoPlistPath)
+	}
+
+	if len(referencedSettings) > 0 {
+		updatedProjectFiles, err := u.updateBuildSettings(helper, targetName, configuration, referencedSettings, options)
+		if err != nil {
+			return nil, err
+		}
+		updatedFiles = append(updatedFiles, updatedProjectFiles...)
+	}
+
+	return updatedFiles, nil
+}
+
+func infoPlistVersions(bundleVersion, shortVersion string) []buildSettingValue {
+	versions := []buildSettingValue{{Key: bundleVersionKey, Value: bundleVersion}}
+	if shortVersion != "" {
+		versions = append(versions, buildSettingValue{Key: shortVersionKey, Value: shortVersion})
+	}
+	return versions
+}
+
+// staticInfoPlistPath returns the path of the static Info.plist of a target which generates its Info.plist. It returns
+// false if the target has no static Info.plist. Unlike infoPlistPath, it does not fall back to xcodebuild: the target
+// is treated as one without a static Info.plist if the path cannot be resolved from the project.
+func (u Updater) staticInfoPlistPath(helper *projectmanager.ProjectHelper, targetName, configuration string) (string, bool, error) {
+	settings, err := u.targetBuildSettings(helper, targetName, configuration)
+	if err != nil {
+		return "", false, err
+	}
+
+	if _, ok := settings.definition(infoPlistFileKey); !ok {
+		return "", false, nil
+	}
+
+	infoPlistPath, err := settings.value(infoPlistFileKey)
+	if err != nil {
+		u.logger.Warnf("The static Info.plist path could not be resolved from the project, ignoring it: %s", err)
+		return "", false, nil
+	}
+
+	if pathutil.IsRelativePath(infoPlistPath) {
+		infoPlistPath = filepath.Join(filepath.Dir(helper.XcProj.Path), infoPlistPath)
+	}
+
+	if !fileExists(infoPlistPath) {
+		u.logger.Warnf("The static Info.plist (%s) does not exist, ignoring it", infoPlistPath)
+		return "", false, nil
+	}
+
+	return infoPlistPath, true, nil
+}
+
+func (u Updater) infoPlistPath(helper *projectmanager.ProjectHelper, schemeName, targetName, configuration string) (string, error) {
+	// The Info.plist path can be extracted into an xcconfig file, and it can also contain Xcode env vars, like
+	// `$(SRCROOT)/path/to/Info.plist`. These are resolved from the project file and the referenced xcconfig files first,
+	// so that the step does not depend on Xcode being installed.
+	settings, err := u.targetBuildSettings(helper, targetName, configuration)
+	if err != nil {
+		return "", err
+	}
+
+	infoPlistPath, err := settings.value(infoPlistFileKey)
+	if err != nil {
+		// Some env vars are only defined by Xcode during an xcodebuild execution, so as a last resort we list the build
+		// settings with `xcodebuild -showBuildSettings` to get a valid path value.
+		u.logger.Printf("Info.plist path could not be resolved from the project: %s\n", err)
+		u.logger.Printf("Using xcodebuild to resolve it\n")
+
+		infoPlistPath, err = extractInfoPlistPathWithXcodebuild(helper.XcProj.Path, schemeName, targetName, configuration)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	if pathutil.IsRelativePath(infoPlistPath) {
+		infoPlistPath = filepath.Join(filepath.Dir(helper.XcProj.Path), infoPlistPath)
+	}
+
+	return infoPlistPath, nil
+}
+
+func extractInfoPlistPathWithXcodebuild(projectPath, scheme, target, configuration string) (string, error) {
+	args := []string{"-project", projectPath}
+
+	if target != "" {
+		args = append(args, "-target", target)
+	} else if scheme != "" {
+		args = append(args, "-scheme", scheme)
+	}
+
+	if configuration != "" {
+		args = append(args, "-configuration", configuration)
+	}
+
+	args = append(args, "-showBuildSettings")
+
+	cmd := command.NewFactory(env.NewRepository()).Create("xcodebuild", args, nil)
+	output, err := cmd.RunAndReturnTrimmedCombinedOutput()
+	if err != nil {
+		return "", err
+	}
+
+	path := infoPlistPathFromOutput(output)
+	if path == "" {
+		return "", fmt.Errorf("missing Info.plist file path")
+	}
+
+	return path, nil
+}
+
+func infoPlistPathFromOutput(output string) string {
+	lines := strings.Split(output, "\n")
+	for _, line := range lines {
+		split := strings.Split(line, " = ")
+
+		if len(split) < 2 {
+			continue
+		}
+
+		if strings.TrimSpace(split[0]) != infoPlistFileKey {
+			continue
+		}
+
+		return strings.TrimSpace(split[1])
+	}
+
+	return ""
+}
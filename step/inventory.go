@@ -0,0 +1,143 @@
+package step
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/bitrise-io/go-xcode/v2/autocodesign/projectmanager"
+	"github.com/bitrise-io/go-xcode/xcodeproject/xcodeproj"
+	"github.com/bitrise-io/go-xcode/xcodeproject/xcworkspace"
+)
+
+const inventoryFileName = "version_inventory.json"
+
+// versionInventory lists the version numbers every target of the project or workspace declares, in each build
+// configuration.
+type versionInventory struct {
+	MainTarget targetVersions   `json:"main_target"`
+	Targets    []targetVersions `json:"targets"`
+}
+
+// createVersionInventory reads the version numbers of every native target of the project (or of every project of the
+// workspace) without changing anything, and writes them into a JSON file.
+func (u Updater) createVersionInventory(helper *projectmanager.ProjectHelper, config Config) (Result, error) {
+	projects, err := inventoryProjects(helper, config.ProjectPath)
+	if err != nil {
+		return Result{}, err
+	}
+
+	var inventory versionInventory
+	for _, project := range projects {
+		for _, target := range project.XcProj.Proj.Targets {
+			if target.Type != xcodeproj.NativeTargetType {
+				continue
+			}
+
+			configurations, err := targetConfigurations(project, target.Name, config.Configuration)
+			if err != nil {
+				u.logger.Debugf("Skipping the %s target: %s", target.Name, err)
+				continue
+			}
+
+			for _, configuration := range configurations {
+				inventory.Targets = append(inventory.Targets, u.inventoryTargetVersions(project, config.Scheme, target.Name, configuration))
+			}
+		}
+	}
+
+	inventory.MainTarget = u.inventoryTargetVersions(helper, config.Scheme, helper.MainTarget.Name, helper.Configuration)
+
+	u.logger.Printf("Version numbers:")
+	u.logger.Printf("%s", versionInventoryTable(inventory.Targets))
+
+	inventoryPath, err := writeJSONFile("version-inventory", inventoryFileName, inventory)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to write the version inventory: %w", err)
+	}
+
+	u.logger.Donef("Version inventory written to %s", inventoryPath)
+
+	return Result{
+		BuildVersion:  inventory.MainTarget.BuildVersion.Value,
+		ShortVersion:  inventory.MainTarget.ShortVersion.Value,
+		InventoryPath: inventoryPath,
+	}, nil
+}
+
+// inventoryTargetVersions resolves the version numbers of a target for the inventory. As the inventory is read-only, a
+// target which cannot be resolved (for example because of an unreadable Info.plist) is recorded with the error instead
+// of failing the step.
+func (u Updater) inventoryTargetVersions(helper *projectmanager.ProjectHelper, schemeName, targetName, configuration string) targetVersions {
+	versions, err := u.targetVersions(helper, schemeName, targetName, configuration)
+	if err != nil {
+		u.logger.Warnf("Failed to resolve the version numbers of the %s configuration of the %s target: %s", configuration, targetName, err)
+		return targetVersions{Project: helper.XcProj.Path, Target: targetName, Configuration: configuration, Error: err.Error()}
+	}
+	return versions
+}
+
+// inventoryProjects returns the project, or every project of the workspace. The project of the scheme is reused, the
+// others are opened.
+func inventoryProjects(helper *projectmanager.ProjectHelper, projectPath string) ([]*projectmanager.ProjectHelper, error) {
+	if !xcworkspace.IsWorkspace(projectPath) {
+		return []*projectmanager.ProjectHelper{helper}, nil
+	}
+
+	workspace, err := xcworkspace.Open(projectPath)
+	if err != nil {
+		return nil, err
+	}
+
+	projectPaths, err := workspace.ProjectFileLocations()
+	if err != nil {
+		return nil, err
+	}
+
+	var projects []*projectmanager.ProjectHelper
+	for _, pth := range projectPaths {
+		absPth, err := filepath.Abs(pth)
+		if err != nil {
+			return nil, err
+		}
+
+		if absPth == helper.XcProj.Path {
+			projects = append(projects, helper)
+			continue
+		}
+
+		project, err := xcodeproj.Open(absPth)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open the workspace project (%s): %w", pth, err)
+		}
+
+		projects = append(projects, &projectmanager.ProjectHelper{XcProj: project})
+	}
+
+	return projects, nil
+}
+
+func versionInventoryTable(targets []targetVersions) string {
+	var table strings.Builder
+	writer := tabwriter.NewWriter(&table, 0, 0, 2, ' ', 0)
+
+	_, _ = fmt.Fprintln(writer, "Project\tTarget\tConfiguration\tBuild number\tVersion number")
+	for _, versions := range targets {
+		buildVersion, shortVersion := displayVersion(versions.BuildVersion.Value), displayVersion(versions.ShortVersion.Value)
+		if versions.Error != "" {
+			buildVersion, shortVersion = "<error>", "<error>"
+		}
+
+		_, _ = fmt.Fprintf(writer, "%s\t%s\t%s\t%s\t%s\n",
+			filepath.Base(versions.Project),
+			versions.Target,
+			versions.Configuration,
+			buildVersion,
+			shortVersion,
+		)
+	}
+	_ = writer.Flush()
+
+	return table.String()
+}
@@ -1,26 +1,144 @@
 package step
 
+const (
+	// ModeUpdate updates the version numbers of the project.
+	ModeUpdate = "update"
+	// ModeAssert only compares the version numbers of the project to the expected ones, without changing anything.
+	ModeAssert = "assert"
+	// ModeInventory only reads the version numbers of every target and writes them into a JSON file.
+	ModeInventory = "inventory"
+
+	// VersioningStrategyManual calculates the version numbers from the build and version number inputs.
+	VersioningStrategyManual = "manual"
+	// VersioningStrategyCalVer calculates the version numbers from the current date (YYYY.MM.N and YYYYMMDDNN).
+	VersioningStrategyCalVer = "calver"
+
+	// BuildVersionModeSet sets the build number to the build_version input as-is.
+	BuildVersionModeSet = "set"
+	// BuildVersionModeOffset sets the build number to the build_version input increased by the offset.
+	BuildVersionModeOffset = "offset"
+	// BuildVersionModeIncrementExisting increases the project's current build number by the offset.
+	BuildVersionModeIncrementExisting = "increment_existing"
+	// BuildVersionModeFormula combines the version number with the build_version input increased by the offset.
+	BuildVersionModeFormula = "formula"
+
+	// BuildVersionFormulaPacked concatenates the zero-padded version number components and the build number (1040237).
+	BuildVersionFormulaPacked = "packed"
+	// BuildVersionFormulaDotted appends the build number to the version number as a new component (1.4.37).
+	BuildVersionFormulaDotted = "dotted"
+
+	// BuildVersionSourceInput uses the build_version input as the build number.
+	BuildVersionSourceInput = "input"
+	// BuildVersionSourceGitCommitCount uses the number of commits of the local git repository as the build number.
+	BuildVersionSourceGitCommitCount = "git_commit_count"
+	// BuildVersionSourceGitCommitTimestamp uses the commit timestamp of HEAD (YYYYMMDD.HHMM, UTC) as the build number.
+	BuildVersionSourceGitCommitTimestamp = "git_commit_timestamp"
+
+	// BuildShortVersionSourceInput uses the build_short_version_string input as the version number.
+	BuildShortVersionSourceInput = "input"
+	// BuildShortVersionSourceGitTag parses the version number from the nearest release tag of the local git repository.
+	BuildShortVersionSourceGitTag = "git_tag"
+
+	// ShortVersionBumpNone keeps the version number, or sets it to the build_short_version_string input.
+	ShortVersionBumpNone = "none"
+	// ShortVersionBumpMajor bumps the major component of the current version number.
+	ShortVersionBumpMajor = "major"
+	// ShortVersionBumpMinor bumps the minor component of the current version number.
+	ShortVersionBumpMinor = "minor"
+	// ShortVersionBumpPatch bumps the patch component of the current version number.
+	ShortVersionBumpPatch = "patch"
+	// ShortVersionBumpConventionalCommits bumps the component of the current version number the Conventional Commit
+	// messages since the last release tag call for.
+	ShortVersionBumpConventionalCommits = "conventional_commits"
+
+	// VersionValidationOff writes the build and version numbers without validating them.
+	VersionValidationOff = "off"
+	// VersionValidationStrict fails the step if the build or version number does not meet App Store Connect's rules.
+	VersionValidationStrict = "strict"
+	// VersionValidationSanitize fixes the common mistakes of the build and version numbers, then validates them.
+	VersionValidationSanitize = "sanitize"
+
+	// UpdateProjectLevelSettings updates the project-level version build settings, which affects every target
+	// inheriting them.
+	UpdateProjectLevelSettings = "update"
+	// OverrideProjectLevelSettings keeps the project-level version build settings and adds target-level overrides.
+	OverrideProjectLevelSettings = "override"
+
+	// VersionConsistencyCheckOff skips comparing the version numbers of the archivable targets.
+	VersionConsistencyCheckOff = "off"
+	// VersionConsistencyCheckWarn logs the version number mismatches of the archivable targets as warnings.
+	VersionConsistencyCheckWarn = "warn"
+	// VersionConsistencyCheckFail fails the step if the version numbers of the archivable targets do not match.
+	VersionConsistencyCheckFail = "fail"
+)
+
 type Input struct {
-	ProjectPath             string `env:"project_path,required"`
-	Scheme                  string `env:"scheme,required"`
-	Target                  string `env:"target"`
-	Configuration           string `env:"configuration"`
-	BuildVersion            string `env:"build_version,required"`
-	BuildVersionOffset      int64  `env:"build_version_offset"`
-	BuildShortVersionString string `env:"build_short_version_string"`
-	Verbose                 bool   `env:"verbose,required"`
+	Mode                        string `env:"mode,opt[update,assert,inventory]"`
+	ProjectPath                 string `env:"project_path,required"`
+	Scheme                      string `env:"scheme,required"`
+	Target                      string `env:"target"`
+	Configuration               string `env:"configuration"`
+	VersioningStrategy          string `env:"versioning_strategy,opt[manual,calver]"`
+	BuildVersionMode            string `env:"build_version_mode,opt[set,offset,increment_existing,formula]"`
+	BuildVersionFormula         string `env:"build_version_formula,opt[packed,dotted]"`
+	BuildVersionFormulaDigits   string `env:"build_version_formula_digits"`
+	BuildVersionSource          string `env:"build_version_source,opt[input,git_commit_count,git_commit_timestamp]"`
+	GitBaseRef                  string `env:"git_base_ref"`
+	BuildVersion                string `env:"build_version,required"`
+	BuildVersionOffset          int64  `env:"build_version_offset"`
+	BuildVersionOffsetComponent int    `env:"build_version_offset_component"`
+	MinimumBuildVersion         string `env:"minimum_build_version"`
+	ForceBuildVersionDecrease   bool   `env:"force_build_version_decrease,required"`
+	BuildShortVersionString     string `env:"build_short_version_string"`
+	BuildShortVersionSource     string `env:"build_short_version_source,opt[input,git_tag]"`
+	GitTagPattern               string `env:"git_tag_pattern"`
+	GitTagBumpPatch             bool   `env:"git_tag_bump_patch,required"`
+	GitTagStripPreRelease       bool   `env:"git_tag_strip_pre_release,required"`
+	BuildShortVersionBump       string `env:"build_short_version_bump,opt[none,major,minor,patch,conventional_commits]"`
+	ProjectLevelSettings        string `env:"project_level_settings,opt[update,override]"`
+	SDKFilter                   string `env:"sdk_filter"`
+	UpdateEmbeddedTargets       bool   `env:"update_embedded_targets,required"`
+	VersionConsistencyCheck     string `env:"version_consistency_check,opt[off,warn,fail]"`
+	VersionValidation           string `env:"version_validation,opt[off,strict,sanitize]"`
+	DryRun                      bool   `env:"dry_run,required"`
+	Verbose                     bool   `env:"verbose,required"`
 }
 
 type Config struct {
-	ProjectPath             string
-	Scheme                  string
-	Target                  string
-	Configuration           string
-	BuildVersion            string
-	BuildVersionOffset      int64
-	BuildShortVersionString string
+	Mode                        string
+	ProjectPath                 string
+	Scheme                      string
+	Target                      string
+	Configuration               string
+	VersioningStrategy          string
+	BuildVersionMode            string
+	BuildVersionFormula         string
+	BuildVersionFormulaDigits   []int
+	BuildVersionSource          string
+	GitBaseRef                  string
+	BuildVersion                string
+	BuildVersionOffset          int64
+	BuildVersionOffsetComponent int
+	MinimumBuildVersion         string
+	ForceBuildVersionDecrease   bool
+	BuildShortVersionString     string
+	BuildShortVersionSource     string
+	GitTagPatterns              []string
+	GitTagBumpPatch             bool
+	GitTagStripPreRelease       bool
+	BuildShortVersionBump       string
+	ProjectLevelSettings        string
+	SDKFilter                   []string
+	UpdateEmbeddedTargets       bool
+	VersionConsistencyCheck     string
+	VersionValidation           string
+	DryRun                      bool
 }
 
 type Result struct {
-	BuildVersion string
+	BuildVersion          string
+	ShortVersion          string
+	PreviousShortVersion  string
+	VersionBumpReportPath string
+	InventoryPath         string
 }
@@ -0,0 +1,188 @@
+package step
+
+import (
+	"path"
+	"strings"
+
+	"github.com/bitrise-io/go-xcode/v2/autocodesign/projectmanager"
+	"github.com/bitrise-io/go-xcode/xcodeproject/xcodeproj"
+)
+
+// buildSettingUpdateOptions control how the version build settings are updated in the project.
+type buildSettingUpdateOptions struct {
+	// OverrideProjectLevel adds target-level overrides instead of updating the project-level definitions.
+	OverrideProjectLevel bool
+	// SDKFilter limits which SDK-conditional variants (like `MARKETING_VERSION[sdk=macosx*]`) are updated. Every
+	// variant is updated if it is empty.
+	SDKFilter []string
+}
+
+// buildSettingValue is a new value of a build setting.
+type buildSettingValue struct {
+	Key   string
+	Value string
+}
+
+func (u Updater) updateVersionNumbersInProject(helper *projectmanager.ProjectHelper, targetName, configuration string, bundleVersion, shortVersion string, options buildSettingUpdateOptions) ([]string, error) {
+	values := []buildSettingValue{{Key: projectVersionKey, Value: bundleVersion}}
+	if shortVersion != "" {
+		values = append(values, buildSettingValue{Key: marketingVersionKey, Value: shortVersion})
+	}
+
+	return u.updateBuildSettings(helper, targetName, configuration, values, options)
+}
+
+// updateBuildSettings updates the given build settings of the target, in every configuration if configuration is empty.
+// It returns the paths of the updated files.
+func (u Updater) updateBuildSettings(helper *projectmanager.ProjectHelper, targetName, configuration string, values []buildSettingValue, options buildSettingUpdateOptions) ([]string, error) {
+	if targetName == "" {
+		targetName = helper.MainTarget.Name
+	}
+
+	resolver := newBuildSettingsResolver(helper.XcProj, u.files, u.logger)
+
+	for _, target := range helper.XcProj.Proj.Targets {
+		if target.Name != targetName {
+			continue
+		}
+
+		for _, buildConfig := range target.BuildConfigurationList.BuildConfigurations {
+			if configuration != "" && buildConfig.Name != configuration {
+				continue
+			}
+
+			u.logger.Printf("Updating build settings for the %s target", target.Name)
+
+			var updated []string
+			for _, value := range values {
+				updatedVariants, err := u.updateBuildSettingVariants(resolver, target.Name, buildConfig, value.Key, value.Value, options)
+				if err != nil {
+					return nil, err
+				}
+				updated = append(updated, updatedVariants...)
+			}
+
+			u.logger.Printf("Updated %s build settings: %s", buildConfig.Name, strings.Join(updated, ", "))
+		}
+	}
+
+	return resolver.save()
+}
+
+// updateBuildSettingVariants updates the unconditional value of the build setting and its conditional variants
+// matching the SDK filter. It returns the updated keys, including their conditions.
+func (u Updater) updateBuildSettingVariants(resolver *buildSettingsResolver, targetName string, buildConfig xcodeproj.BuildConfiguration, key, value string, options buildSettingUpdateOptions) ([]string, error) {
+	settings, err := resolver.targetBuildSettings(targetName, buildConfig.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	updated := []string{key}
+	if err := u.updateBuildSetting(resolver, targetName, buildConfig, key, "", value, options.OverrideProjectLevel); err != nil {
+		return nil, err
+	}
+
+	for _, condition := range settings.conditions(key) {
+		if !matchesSDKFilter(condition, options.SDKFilter) {
+			u.logger.Debugf("Skipping %s%s, it does not match the SDK filter", key, condition)
+			continue
+		}
+
+		if err := u.updateBuildSetting(resolver, targetName, buildConfig, key, condition, value, options.OverrideProjectLevel); err != nil {
+			return nil, err
+		}
+		updated = append(updated, key+condition)
+	}
+
+	return updated, nil
+}
+
+// updateBuildSetting updates the build setting where it is defined (target, project or xcconfig file), so that the new
+// value is not shadowed by the existing definition. If the setting is not defined yet, it is added to the target.
+//
+// If the setting only references another one (like `MARKETING_VERSION = $(APP_VERSION)`), the reference is kept and
+// the referenced setting holding the literal value is updated instead.
+//
+// Project-level definitions are shared by every target of the project. When overrideProjectLevel is set, these are kept
+// as-is and a target-level override is added instead.
+func (u Updater) updateBuildSetting(resolver *buildSettingsResolver, targetName string, buildConfig xcodeproj.BuildConfiguration, key, condition, value string, overrideProjectLevel bool) error {
+	settings, err := resolver.targetBuildSettings(targetName, buildConfig.Name)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := settings.conditionalDefinition(key, condition); !ok {
+		resolver.setProjectValue(buildConfig, key+condition, value)
+
+		u.logger.Debugf("%s%s -> %s", key, condition, value)
+
+		return nil
+	}
+
+	definition, chain, err := settings.referencedDefinition(key, condition)
+	if err != nil {
+		return err
+	}
+	if len(chain) > 1 {
+		u.logger.Printf("%s references %s, updating it at %s", chain[0], strings.Join(chain[1:], " -> "), definition.Location())
+	}
+
+	name := definition.Key + definition.Condition
+	if definition.isProjectLevel() {
+		if overrideProjectLevel {
+			u.logger.Printf("%s is defined at the project level (%s), adding a target-level override", name, definition.Location())
+
+			resolver.setProjectValue(buildConfig, name, value)
+
+			u.logger.Debugf("%s -> %s", name, value)
+
+			return nil
+		}
+
+		u.logger.Printf("%s is defined at the project level (%s), updating it for every target of the project", name, definition.Location())
+	}
+
+	resolver.setValue(definition, value)
+
+	u.logger.Debugf("%s %s -> %s (%s)", name, definition.Value, value, definition.Location())
+
+	return nil
+}
+
+// matchesSDKFilter reports whether a build setting condition (like `[sdk=iphoneos*][arch=arm64]`) matches any of the
+// SDK patterns. Conditions without an SDK part apply to every SDK, so they always match.
+func matchesSDKFilter(condition string, sdkFilter []string) bool {
+	if len(sdkFilter) == 0 {
+		return true
+	}
+
+	sdk := conditionValue(condition, "sdk")
+	if sdk == "" {
+		return true
+	}
+
+	for _, pattern := range sdkFilter {
+		if pattern == sdk {
+			return true
+		}
+		if matched, err := path.Match(sdk, pattern); err == nil && matched {
+			return true
+		}
+		if matched, err := path.Match(pattern, sdk); err == nil && matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseList splits a comma separated input value.
+func parseList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
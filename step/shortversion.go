@@ -0,0 +1,75 @@
+package step
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// bumpShortVersion reads the current version number of the targets and bumps its major, minor or patch component. It
+// returns the current and the bumped version number. Every target and configuration has to have the same current
+// version number.
+func (u Updater) bumpShortVersion(targets []bundleTarget, config Config) (string, string, error) {
+	if config.BuildShortVersionString != "" {
+		return "", "", fmt.Errorf("the version number (%s) and the version number bump (%s) cannot be used together", config.BuildShortVersionString, config.BuildShortVersionBump)
+	}
+
+	allVersions, err := u.allTargetVersions(targets, config)
+	if err != nil {
+		return "", "", err
+	}
+
+	current, err := currentVersion(allVersions, "version number", func(versions targetVersions) versionValue {
+		return versions.ShortVersion
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("the current version number cannot be bumped: %w", err)
+	}
+
+	bumped, err := bumpVersion(current, config.BuildShortVersionBump)
+	if err != nil {
+		return "", "", err
+	}
+
+	u.logger.Printf("Bumping the %s component of the current version number (%s): %s", config.BuildShortVersionBump, current, bumped)
+
+	return current, bumped, nil
+}
+
+// bumpVersion increments the given component of the version number and resets the lower ones. The version number has
+// to be one to three period-separated integers, the format App Store Connect accepts for CFBundleShortVersionString.
+// The number of components is kept, unless the bumped component is missing.
+func bumpVersion(version, bump string) (string, error) {
+	numbers, err := parseVersionComponents(version)
+	if err != nil || len(numbers) > 3 {
+		return "", fmt.Errorf("the current version number (%s) is not one to three period-separated integers", displayVersion(version))
+	}
+
+	var index int
+	switch bump {
+	case ShortVersionBumpMajor:
+		index = 0
+	case ShortVersionBumpMinor:
+		index = 1
+	case ShortVersionBumpPatch:
+		index = 2
+	default:
+		return "", fmt.Errorf("unknown version number bump: %s", bump)
+	}
+
+	for len(numbers) <= index {
+		numbers = append(numbers, 0)
+	}
+
+	numbers[index]++
+	for i := index + 1; i < len(numbers); i++ {
+		numbers[i] = 0
+	}
+
+	var bumped []string
+	for _, number := range numbers {
+		bumped = append(bumped, strconv.FormatUint(number, 10))
+	}
+
+	return strings.Join(bumped, "."), nil
+}
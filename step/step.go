@@ -2,31 +2,31 @@ package step
 
 import (
 	"fmt"
-	"path/filepath"
-	"regexp"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/bitrise-io/go-steputils/v2/export"
 	"github.com/bitrise-io/go-steputils/v2/stepconf"
-	"github.com/bitrise-io/go-utils/pathutil"
-	"github.com/bitrise-io/go-utils/v2/command"
-	"github.com/bitrise-io/go-utils/v2/env"
+	"github.com/bitrise-io/go-utils/sliceutil"
 	"github.com/bitrise-io/go-utils/v2/log"
 	"github.com/bitrise-io/go-xcode/v2/autocodesign/projectmanager"
-	"github.com/bitrise-io/go-xcode/xcodeproject/serialized"
-	"github.com/bitrise-io/go-xcode/xcodeproject/xcodeproj"
 )
 
 const (
-	infoPlistFileKey = "INFOPLIST_FILE"
-	envVarRegex      = `^.*\$\(.+\).*$`
+	infoPlistFileKey    = "INFOPLIST_FILE"
+	bundleVersionKey    = "CFBundleVersion"
+	shortVersionKey     = "CFBundleShortVersionString"
+	projectVersionKey   = "CURRENT_PROJECT_VERSION"
+	marketingVersionKey = "MARKETING_VERSION"
 )
 
 type Updater struct {
 	inputParser stepconf.InputParser
 	exporter    export.Exporter
 	logger      log.Logger
+	files       *fileStore
+	now         func() time.Time
 }
 
 func NewUpdater(inputParser stepconf.InputParser, exporter export.Exporter, logger log.Logger) Updater {
@@ -34,6 +34,7 @@ func NewUpdater(inputParser stepconf.InputParser, exporter export.Exporter, logg
 		inputParser: inputParser,
 		exporter:    exporter,
 		logger:      logger,
+		now:         time.Now,
 	}
 }
 
@@ -49,287 +50,425 @@ func (u Updater) ProcessConfig() (Config, error) {
 	stepconf.Print(input)
 	u.logger.Println()
 
+	if input.BuildVersionOffsetComponent < 0 {
+		return Config{}, fmt.Errorf("build version offset component (%d) cannot be negative", input.BuildVersionOffsetComponent)
+	}
+
+	if input.BuildShortVersionSource == BuildShortVersionSourceGitTag && input.GitTagBumpPatch && input.BuildShortVersionBump != "" && input.BuildShortVersionBump != ShortVersionBumpNone {
+		return Config{}, fmt.Errorf("git tag patch bump cannot be combined with the %s version number bump, as both would bump the version of the git tag", input.BuildShortVersionBump)
+	}
+
+	formulaDigits, err := parseFormulaDigits(input.BuildVersionFormulaDigits)
+	if err != nil {
+		return Config{}, err
+	}
+
 	return Config{
-		ProjectPath:             input.ProjectPath,
-		Scheme:                  input.Scheme,
-		Target:                  input.Target,
-		Configuration:           input.Configuration,
-		BuildVersion:            input.BuildVersion,
-		BuildVersionOffset:      input.BuildVersionOffset,
-		BuildShortVersionString: input.BuildShortVersionString,
+		Mode:                        input.Mode,
+		ProjectPath:                 input.ProjectPath,
+		Scheme:                      input.Scheme,
+		Target:                      input.Target,
+		Configuration:               input.Configuration,
+		VersioningStrategy:          input.VersioningStrategy,
+		BuildVersionMode:            input.BuildVersionMode,
+		BuildVersionFormula:         input.BuildVersionFormula,
+		BuildVersionFormulaDigits:   formulaDigits,
+		BuildVersionSource:          input.BuildVersionSource,
+		GitBaseRef:                  input.GitBaseRef,
+		BuildVersion:                input.BuildVersion,
+		BuildVersionOffset:          input.BuildVersionOffset,
+		BuildVersionOffsetComponent: input.BuildVersionOffsetComponent,
+		MinimumBuildVersion:         input.MinimumBuildVersion,
+		ForceBuildVersionDecrease:   input.ForceBuildVersionDecrease,
+		BuildShortVersionString:     input.BuildShortVersionString,
+		BuildShortVersionSource:     input.BuildShortVersionSource,
+		GitTagPatterns:              parseList(input.GitTagPattern),
+		GitTagBumpPatch:             input.GitTagBumpPatch,
+		GitTagStripPreRelease:       input.GitTagStripPreRelease,
+		BuildShortVersionBump:       input.BuildShortVersionBump,
+		ProjectLevelSettings:        input.ProjectLevelSettings,
+		SDKFilter:                   parseList(input.SDKFilter),
+		UpdateEmbeddedTargets:       input.UpdateEmbeddedTargets,
+		VersionConsistencyCheck:     input.VersionConsistencyCheck,
+		VersionValidation:           input.VersionValidation,
+		DryRun:                      input.DryRun,
 	}, nil
 }
 
 func (u Updater) Run(config Config) (Result, error) {
+	u.files = newFileStore(config.DryRun)
+
 	helper, err := projectmanager.NewProjectHelper(config.ProjectPath, config.Scheme, config.Configuration)
 	if err != nil {
 		return Result{}, err
 	}
 
-	generated, err := generatesInfoPlist(helper, config.Target, config.Configuration)
+	if config.Mode == ModeInventory {
+		return u.createVersionInventory(helper, config)
+	}
+
+	options := buildSettingUpdateOptions{
+		OverrideProjectLevel: config.ProjectLevelSettings == OverrideProjectLevelSettings,
+		SDKFilter:            config.SDKFilter,
+	}
+
+	targets, err := u.targetsToUpdate(helper, config)
 	if err != nil {
 		return Result{}, err
 	}
 
-	config.BuildVersion, err = incrementBuildVersion(u.logger, config.BuildVersion, config.BuildVersionOffset)
+	config = u.sanitizeVersionNumbers(config)
+
+	result, err := u.resolveVersionNumbers(targets, config)
 	if err != nil {
 		return Result{}, err
 	}
+	config.BuildVersion = result.BuildVersion
+	config.BuildShortVersionString = result.ShortVersion
 
-	if generated {
-		u.logger.Printf("The version numbers are stored in the project file.")
-
-		err := u.updateVersionNumbersInProject(helper, config.Target, config.Configuration, config.BuildVersion, config.BuildShortVersionString)
-		if err != nil {
+	if config.Mode == ModeAssert {
+		if err := u.assertVersionNumbers(targets, config); err != nil {
 			return Result{}, err
 		}
-	} else {
-		u.logger.Printf("The version numbers are stored in the plist file.")
 
-		err := u.updateVersionNumbersInInfoPlist(helper, config.Scheme, config.Target, config.Configuration, config.BuildVersion, config.BuildShortVersionString)
+		exported := configurationVersions(config, helper.Configuration)
+		result.BuildVersion = exported.BuildVersion
+		result.ShortVersion = exported.BuildShortVersionString
+
+		return result, nil
+	}
+
+	if err := u.validateVersionNumbers(targets, config); err != nil {
+		return Result{}, err
+	}
+
+	if err := u.checkBuildVersionDecrease(targets, config); err != nil {
+		return Result{}, err
+	}
+
+	var versionsBefore []targetVersions
+	if config.DryRun {
+		versionsBefore, err = u.allTargetVersions(targets, config)
 		if err != nil {
 			return Result{}, err
 		}
 	}
 
-	u.logger.Donef("Version numbers successfully updated.")
+	var updatedFiles []string
+	for _, target := range targets {
+		targetName := target.name
+		configurations, err := targetConfigurations(target.helper, targetName, config.Configuration)
+		if err != nil {
+			return Result{}, err
+		}
 
-	return Result{BuildVersion: config.BuildVersion}, nil
-}
+		// The version storage can differ between the build configurations (for example Info-Debug.plist and
+		// Info-Release.plist, or a generated Info.plist in only one of them), so each configuration is handled on its own.
+		for _, configuration := range configurations {
+			u.logger.Println()
+			u.logger.Infof("Updating the %s configuration of the %s target", configuration, targetName)
 
-func (u Updater) Export(result Result) error {
-	return u.exporter.ExportOutput("XCODE_BUNDLE_VERSION", result.BuildVersion)
-}
+			files, err := u.updateVersionNumbers(target.helper, configurationVersions(config, configuration), targetName, configuration, options)
+			if err != nil {
+				return Result{}, fmt.Errorf("failed to update the %s configuration of the %s target: %w", configuration, targetName, err)
+			}
 
-func generatesInfoPlist(helper *projectmanager.ProjectHelper, targetName, configuration string) (bool, error) {
-	buildConfig, err := buildConfiguration(helper, targetName, configuration)
-	if err != nil {
-		return false, err
+			for _, file := range files {
+				if !sliceutil.IsStringInSlice(file, updatedFiles) {
+					updatedFiles = append(updatedFiles, file)
+				}
+			}
+		}
 	}
 
-	generatesInfoPlist := buildConfig.BuildSettings["GENERATE_INFOPLIST_FILE"] == "YES"
-
-	return generatesInfoPlist, err
-}
-
-func incrementBuildVersion(logger log.Logger, buildVersion string, offset int64) (string, error) {
-	// Check if build version is numeric
-	parsedBuildVersion, err := strconv.ParseInt(buildVersion, 10, 64)
-	if err != nil {
-		logger.Infof("Provided build version is not numeric (%s), using it as-is without incrementing", buildVersion)
-		if offset != 0 {
-			return "", fmt.Errorf("build version offset (%d) cannot be applied to non-numeric build version (%s), use 0 as the offset to use the build version as-is", offset, buildVersion)
+	if config.DryRun {
+		if err := u.printDryRunChanges(targets, config, versionsBefore); err != nil {
+			return Result{}, err
+		}
+	} else {
+		u.logger.Println()
+		u.logger.Printf("Updated files:")
+		for _, file := range updatedFiles {
+			u.logger.Printf("- %s", file)
 		}
-		return buildVersion, nil
 	}
 
-	// Numeric build version provided, increment it
-	if offset >= 0 {
-		return strconv.FormatInt(parsedBuildVersion+offset, 10), nil
+	if err := u.checkVersionConsistency(helper, config); err != nil {
+		return Result{}, err
 	}
-	logger.Infof("Build version offset is negative (%d), skipping version increment.", offset)
-	return buildVersion, nil
-}
 
-func (u Updater) updateVersionNumbersInProject(helper *projectmanager.ProjectHelper, targetName, configuration string, bundleVersion, shortVersion string) error {
-	if targetName == "" {
-		targetName = helper.MainTarget.Name
+	if config.DryRun {
+		u.logger.Donef("Dry run finished, no files were changed.")
+	} else {
+		u.logger.Donef("Version numbers successfully updated.")
 	}
 
-	for _, target := range helper.XcProj.Proj.Targets {
-		if target.Name != targetName {
-			continue
-		}
-
-		for _, buildConfig := range target.BuildConfigurationList.BuildConfigurations {
-			if configuration != "" && buildConfig.Name != configuration {
-				continue
-			}
+	// The exported values are the ones of the configuration the scheme is archived with.
+	exported := configurationVersions(config, helper.Configuration)
+	result.BuildVersion = exported.BuildVersion
+	result.ShortVersion = exported.BuildShortVersionString
 
-			u.logger.Printf("Updating build settings for the %s target", target.Name)
+	return result, nil
+}
 
-			oldProjectVersion := buildConfig.BuildSettings["CURRENT_PROJECT_VERSION"]
-			buildConfig.BuildSettings["CURRENT_PROJECT_VERSION"] = bundleVersion
+func (u Updater) Export(result Result) error {
+	if err := u.exporter.ExportOutput("XCODE_BUNDLE_VERSION", result.BuildVersion); err != nil {
+		return err
+	}
 
-			u.logger.Debugf("CURRENT_PROJECT_VERSION %s -> %s", oldProjectVersion, bundleVersion)
+	if result.ShortVersion != "" {
+		if err := u.exporter.ExportOutput("XCODE_BUNDLE_SHORT_VERSION_STRING", result.ShortVersion); err != nil {
+			return err
+		}
+	}
 
-			if shortVersion != "" {
-				oldMarketingVersion := buildConfig.BuildSettings["MARKETING_VERSION"]
-				buildConfig.BuildSettings["MARKETING_VERSION"] = shortVersion
+	if result.PreviousShortVersion != "" {
+		if err := u.exporter.ExportOutput("XCODE_BUNDLE_PREVIOUS_SHORT_VERSION_STRING", result.PreviousShortVersion); err != nil {
+			return err
+		}
+	}
 
-				u.logger.Debugf("MARKETING_VERSION %s -> %s", oldMarketingVersion, shortVersion)
-			}
+	if result.VersionBumpReportPath != "" {
+		if err := u.exporter.ExportOutput("XCODE_VERSION_BUMP_REPORT_PATH", result.VersionBumpReportPath); err != nil {
+			return err
 		}
 	}
 
-	err := helper.XcProj.Save()
-	if err != nil {
-		return err
+	if result.InventoryPath != "" {
+		if err := u.exporter.ExportOutput("XCODE_VERSION_INVENTORY_PATH", result.InventoryPath); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-func (u Updater) updateVersionNumbersInInfoPlist(helper *projectmanager.ProjectHelper, schemeName, targetName, configuration string, bundleVersion, shortVersion string) error {
-	buildConfig, err := buildConfiguration(helper, targetName, configuration)
-	if err != nil {
-		return err
+// resolveVersionNumbers returns the build number and the version number to set according to the versioning strategy,
+// and the previous version number if it is calculated from the current one.
+func (u Updater) resolveVersionNumbers(targets []bundleTarget, config Config) (Result, error) {
+	if config.VersioningStrategy == VersioningStrategyCalVer {
+		current, next, err := u.calendarVersions(targets, config, u.now().UTC())
+		if err != nil {
+			return Result{}, err
+		}
+		return Result{
+			BuildVersion:         next.BuildVersion.Value,
+			ShortVersion:         next.ShortVersion.Value,
+			PreviousShortVersion: current.ShortVersion.Value,
+		}, nil
 	}
 
-	infoPlistPath, err := buildConfig.BuildSettings.String(infoPlistFileKey)
-	// If the path is extracted into a xcconfig file, then it will not appear here in the build settings.
-	// We need to use xcodebuild to resolve the path.
+	config, err := u.renderVersionTemplates(targets, config, u.now().UTC())
 	if err != nil {
-		if !serialized.IsKeyNotFoundError(err) {
-			return err
+		return Result{}, err
+	}
+
+	var result Result
+	shortVersion := config.BuildShortVersionString
+	if config.BuildShortVersionSource == BuildShortVersionSourceGitTag {
+		if shortVersion != "" {
+			u.logger.Warnf("Version number (%s) is ignored, the version number is read from the git tags", shortVersion)
 		}
 
-		u.logger.Printf("Info.plist path was not found in the project\n")
-		u.logger.Printf("Using xcodebuild to resolve it\n")
+		shortVersion, err = u.gitTagShortVersion(config)
+		if err != nil {
+			return Result{}, err
+		}
+	}
 
-		infoPlistPath, err = extractInfoPlistPathWithXcodebuild(helper.XcProj.Path, schemeName, targetName, configuration)
+	var report *versionBumpReport
+	if config.BuildShortVersionBump == ShortVersionBumpConventionalCommits {
+		report, err = u.conventionalCommitsBump(config)
 		if err != nil {
-			return err
+			return Result{}, err
 		}
+		config.BuildShortVersionBump = report.Bump
 	}
 
-	// By default, the setting for the Info.plist file path is a relative path from the project file. Of course,
-	// developers can override this with something more custom to their setup. They can also use Xcode env vars as part
-	// of their path.
-	//
-	// An example from a SWAT ticket: `$(SRCROOT)/path/to/Info.plist`.
-	//
-	// The problem with this is that it is not a real path until the env var is resolved. And in this case, Xcode
-	// will define this env var, so we only know its value during an xcodebuild execution. So if we see an env var in
-	// the path, then we have to list the build settings with `xcodebuild -showBuildSettings` to get a valid path value.
-	if hasEnvVars(infoPlistPath) {
-		u.logger.Printf("Info.plist path contains env var: %s\n", infoPlistPath)
-		u.logger.Printf("Using xcodebuild to resolve it\n")
-
-		infoPlistPath, err = extractInfoPlistPathWithXcodebuild(helper.XcProj.Path, schemeName, targetName, configuration)
+	switch {
+	case config.BuildShortVersionBump == "" || config.BuildShortVersionBump == ShortVersionBumpNone:
+		result.ShortVersion = shortVersion
+	case config.BuildShortVersionSource == BuildShortVersionSourceGitTag:
+		result.ShortVersion, err = bumpVersion(shortVersion, config.BuildShortVersionBump)
 		if err != nil {
-			return err
+			return Result{}, err
+		}
+		result.PreviousShortVersion = shortVersion
+
+		u.logger.Printf("Bumping the %s component of the version number from the git tag (%s): %s", config.BuildShortVersionBump, shortVersion, result.ShortVersion)
+	default:
+		result.PreviousShortVersion, result.ShortVersion, err = u.bumpShortVersion(targets, config)
+		if err != nil {
+			return Result{}, err
 		}
 	}
 
-	if pathutil.IsRelativePath(infoPlistPath) {
-		infoPlistPath = filepath.Join(filepath.Dir(helper.XcProj.Path), infoPlistPath)
+	// The build number formula builds on the resolved version number.
+	config.BuildShortVersionString = result.ShortVersion
+	result.BuildVersion, err = u.buildVersion(targets, config)
+	if err != nil {
+		return Result{}, err
 	}
 
-	u.logger.Printf("Updating Info.plist at %s", infoPlistPath)
+	if report != nil {
+		report.PreviousVersion = result.PreviousShortVersion
+		report.Version = result.ShortVersion
 
-	infoPlist, format, err := xcodeproj.ReadPlistFile(infoPlistPath)
-	if err != nil {
-		return err
+		result.VersionBumpReportPath, err = writeJSONFile("version-bump-report", versionBumpReportFileName, report)
+		if err != nil {
+			return Result{}, fmt.Errorf("failed to write the version bump report: %w", err)
+		}
+		u.logger.Printf("Version bump report written to %s", result.VersionBumpReportPath)
 	}
 
-	oldVersion := infoPlist["CFBundleVersion"]
-	infoPlist["CFBundleVersion"] = bundleVersion
+	return result, nil
+}
 
-	u.logger.Debugf("CFBundleVersion %s -> %s", oldVersion, bundleVersion)
+// targetsToUpdate returns the target to update, followed by its embedded targets (app extensions, watch apps, App Clips,
+// login items, XPC services) if the embedded targets should be updated too.
+func (u Updater) targetsToUpdate(helper *projectmanager.ProjectHelper, config Config) ([]bundleTarget, error) {
+	target := helper.MainTarget
+	if config.Target != "" && config.Target != target.Name {
+		var ok bool
+		target, ok = helper.XcProj.Proj.TargetByName(config.Target)
+		if !ok {
+			return nil, fmt.Errorf("target not found: %s", config.Target)
+		}
+	}
 
-	if shortVersion != "" {
-		oldVersionString := infoPlist["CFBundleShortVersionString"]
-		infoPlist["CFBundleShortVersionString"] = shortVersion
+	targets := []bundleTarget{{helper: helper, name: target.Name}}
+	if !config.UpdateEmbeddedTargets {
+		return targets, nil
+	}
 
-		u.logger.Debugf("CFBundleShortVersionString %s -> %s", oldVersionString, shortVersion)
+	finder, err := newEmbeddedTargetFinder(u.logger, helper, config.ProjectPath)
+	if err != nil {
+		return nil, err
 	}
 
-	err = xcodeproj.WritePlistFile(infoPlistPath, infoPlist, format)
+	embeddedTargets, err := finder.embeddedTargets(helper, target)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to find the embedded targets of %s: %w", target.Name, err)
 	}
 
-	return nil
-}
+	if len(embeddedTargets) == 0 {
+		u.logger.Printf("The %s target has no embedded targets", target.Name)
+		return targets, nil
+	}
 
-func hasEnvVars(path string) bool {
-	re := regexp.MustCompile(envVarRegex)
-	containsEnvVar := re.Match([]byte(path))
+	var names []string
+	for _, embeddedTarget := range embeddedTargets {
+		names = append(names, embeddedTarget.name)
+	}
+	u.logger.Printf("Updating the %s target and its embedded targets: %s", target.Name, strings.Join(names, ", "))
 
-	return containsEnvVar
+	return append(targets, embeddedTargets...), nil
 }
 
-func extractInfoPlistPathWithXcodebuild(projectPath, scheme, target, configuration string) (string, error) {
-	args := []string{"-project", projectPath}
-
-	if target != "" {
-		args = append(args, "-target", target)
-	} else if scheme != "" {
-		args = append(args, "-scheme", scheme)
+// updateVersionNumbers detects how the given configuration of the target stores its version numbers, updates them
+// and returns the paths of the updated files.
+func (u Updater) updateVersionNumbers(helper *projectmanager.ProjectHelper, config Config, targetName, configuration string, options buildSettingUpdateOptions) ([]string, error) {
+	generated, err := u.generatesInfoPlist(helper, targetName, configuration)
+	if err != nil {
+		return nil, err
 	}
 
-	if configuration != "" {
-		args = append(args, "-configuration", configuration)
-	}
+	if !generated {
+		u.logger.Printf("The version numbers are stored in the plist file.")
 
-	args = append(args, "-showBuildSettings")
+		return u.updateVersionNumbersInInfoPlist(helper, config.Scheme, targetName, configuration, config.BuildVersion, config.BuildShortVersionString, options)
+	}
 
-	cmd := command.NewFactory(env.NewRepository()).Create("xcodebuild", args, nil)
-	output, err := cmd.RunAndReturnTrimmedCombinedOutput()
+	infoPlistPath, hybrid, err := u.staticInfoPlistPath(helper, targetName, configuration)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	path := infoPlistPathFromOutput(output)
-	if path == "" {
-		return "", fmt.Errorf("missing Info.plist file path")
+	if hybrid {
+		u.logger.Printf("The Info.plist is generated from the project file and merged with a static one (%s).", infoPlistPath)
+
+		return u.updateVersionNumbersInHybridInfoPlist(helper, infoPlistPath, targetName, configuration, config.BuildVersion, config.BuildShortVersionString, options)
 	}
 
-	return path, nil
-}
+	u.logger.Printf("The version numbers are stored in the project file.")
 
-func infoPlistPathFromOutput(output string) string {
-	lines := strings.Split(output, "\n")
-	for _, line := range lines {
-		split := strings.Split(line, " = ")
+	return u.updateVersionNumbersInProject(helper, targetName, configuration, config.BuildVersion, config.BuildShortVersionString, options)
+}
 
-		if len(split) < 2 {
-			continue
-		}
+// targetConfigurations returns the given configuration, or every configuration of the target if it is empty.
+func targetConfigurations(helper *projectmanager.ProjectHelper, targetName, configuration string) ([]string, error) {
+	target, ok := helper.XcProj.Proj.TargetByName(targetName)
+	if !ok {
+		return nil, fmt.Errorf("target not found: %s", targetName)
+	}
 
-		if strings.TrimSpace(split[0]) != infoPlistFileKey {
-			continue
+	var configurations []string
+	for _, buildConfig := range target.BuildConfigurationList.BuildConfigurations {
+		if configuration == "" || buildConfig.Name == configuration {
+			configurations = append(configurations, buildConfig.Name)
 		}
+	}
 
-		return strings.TrimSpace(split[1])
+	if len(configurations) == 0 {
+		return nil, fmt.Errorf("configuration %s not found in the %s target", configuration, targetName)
 	}
 
-	return ""
+	return configurations, nil
 }
 
-func buildConfiguration(helper *projectmanager.ProjectHelper, targetName, configuration string) (*xcodeproj.BuildConfiguration, error) {
-	if targetName == "" {
-		targetName = helper.MainTarget.Name
+func (u Updater) generatesInfoPlist(helper *projectmanager.ProjectHelper, targetName, configuration string) (bool, error) {
+	settings, err := u.targetBuildSettings(helper, targetName, configuration)
+	if err != nil {
+		return false, err
 	}
 
-	if configuration == "" {
-		configuration = helper.MainTarget.BuildConfigurationList.DefaultConfigurationName
+	// The setting can be inherited from the project-level build settings or from an xcconfig file.
+	value, err := settings.value("GENERATE_INFOPLIST_FILE")
+	if err != nil {
+		if _, ok := err.(undefinedBuildSettingError); ok {
+			return false, nil
+		}
+		return false, err
 	}
 
-	var xcodeprojTarget *xcodeproj.Target
-	for _, target := range helper.XcProj.Proj.Targets {
-		if target.Name == targetName {
-			xcodeprojTarget = &target
-			break
+	return value == "YES", nil
+}
+
+func incrementBuildVersion(logger log.Logger, buildVersion string, offset int64, component int) (string, error) {
+	// Check if build version is numeric
+	parsedBuildVersion, err := strconv.ParseInt(buildVersion, 10, 64)
+	if err != nil {
+		if dottedBuildVersionRegexp.MatchString(buildVersion) {
+			return incrementDottedBuildVersion(logger, buildVersion, offset, component)
+		}
+
+		logger.Infof("Provided build version is not numeric (%s), using it as-is without incrementing", buildVersion)
+		if offset != 0 {
+			return "", fmt.Errorf("build version offset (%d) cannot be applied to non-numeric build version (%s), use 0 as the offset to use the build version as-is", offset, buildVersion)
 		}
+		return buildVersion, nil
 	}
 
-	if xcodeprojTarget == nil {
-		return nil, fmt.Errorf("target '%s' not found in project: %s", targetName, helper.XcProj.Path)
+	if component > 1 {
+		return "", fmt.Errorf("build version offset component (%d) is out of range, the build version (%s) has one component", component, buildVersion)
 	}
 
-	var xcodeprojBuildConfiguration *xcodeproj.BuildConfiguration
-	for _, buildConfig := range xcodeprojTarget.BuildConfigurationList.BuildConfigurations {
-		if buildConfig.Name == configuration {
-			xcodeprojBuildConfiguration = &buildConfig
-			break
-		}
+	// Numeric build version provided, increment it
+	if offset >= 0 {
+		return strconv.FormatInt(parsedBuildVersion+offset, 10), nil
+	}
+	logger.Infof("Build version offset is negative (%d), skipping version increment.", offset)
+	return buildVersion, nil
+}
+
+func (u Updater) targetBuildSettings(helper *projectmanager.ProjectHelper, targetName, configuration string) (buildSettings, error) {
+	if targetName == "" {
+		targetName = helper.MainTarget.Name
 	}
 
-	if xcodeprojBuildConfiguration == nil {
-		return nil, fmt.Errorf("build configuration '%s' not found for target '%s' in project: %s", configuration, targetName, helper.XcProj.Path)
+	if configuration == "" {
+		configuration = helper.MainTarget.BuildConfigurationList.DefaultConfigurationName
 	}
 
-	return xcodeprojBuildConfiguration, nil
+	return newBuildSettingsResolver(helper.XcProj, u.files, u.logger).targetBuildSettings(targetName, configuration)
 }
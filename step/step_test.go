@@ -1,13 +1,23 @@
 package step
 
 import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/bitrise-io/go-steputils/v2/export"
 	"github.com/bitrise-io/go-steputils/v2/stepconf"
 	"github.com/bitrise-io/go-utils/v2/command"
 	"github.com/bitrise-io/go-utils/v2/env"
 	"github.com/bitrise-io/go-utils/v2/log"
+	"github.com/bitrise-io/go-xcode/v2/autocodesign/projectmanager"
+	"github.com/bitrise-io/go-xcode/xcodeproject/xcodeproj"
 	"github.com/bitrise-steplib/steps-set-xcode-build-number/step/mocks"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -30,17 +40,84 @@ func TestExport(t *testing.T) {
 	mockFactory.AssertExpectations(t)
 }
 
+func TestExport_inventory(t *testing.T) {
+	result := Result{BuildVersion: "999", ShortVersion: "1.2.3", InventoryPath: "/tmp/version_inventory.json"}
+
+	mockFactory := mocks.NewFactory(t)
+	for key, value := range map[string]string{
+		"XCODE_BUNDLE_VERSION":              result.BuildVersion,
+		"XCODE_BUNDLE_SHORT_VERSION_STRING": result.ShortVersion,
+		"XCODE_VERSION_INVENTORY_PATH":      result.InventoryPath,
+	} {
+		arguments := []string{"add", "--key", key, "--value", value}
+		mockFactory.On("Create", "envman", arguments, (*command.Opts)(nil)).Return(testCommand())
+	}
+
+	updater := NewUpdater(stepconf.NewInputParser(env.NewRepository()), export.NewExporter(mockFactory), log.NewLogger())
+	err := updater.Export(result)
+	assert.NoError(t, err)
+
+	mockFactory.AssertExpectations(t)
+}
+
 func testCommand() command.Command {
 	factory := command.NewFactory(env.NewRepository())
 	return factory.Create("pwd", []string{}, nil)
 }
 
+// inputParserFunc is an input parser which fills the step inputs with a function instead of the environment.
+type inputParserFunc func(input interface{}) error
+
+func (f inputParserFunc) Parse(input interface{}) error {
+	return f(input)
+}
+
+func TestUpdater_ProcessConfig(t *testing.T) {
+	input := Input{
+		ProjectPath:                 "Example.xcodeproj",
+		Scheme:                      "Example",
+		BuildVersion:                "1.2.3.45",
+		BuildVersionOffset:          5,
+		BuildVersionOffsetComponent: 2,
+		BuildVersionFormulaDigits:   "2,3,4",
+		GitTagPattern:               "v*, release/*",
+		SDKFilter:                   "iphoneos,iphonesimulator",
+	}
+	parser := inputParserFunc(func(parsed interface{}) error {
+		*parsed.(*Input) = input
+		return nil
+	})
+
+	config, err := NewUpdater(parser, export.NewExporter(mocks.NewFactory(t)), log.NewLogger()).ProcessConfig()
+	require.NoError(t, err)
+	require.Equal(t, "Example.xcodeproj", config.ProjectPath)
+	require.Equal(t, "Example", config.Scheme)
+	require.Equal(t, "1.2.3.45", config.BuildVersion)
+	require.Equal(t, int64(5), config.BuildVersionOffset)
+	require.Equal(t, 2, config.BuildVersionOffsetComponent)
+	require.Equal(t, []int{2, 3, 4}, config.BuildVersionFormulaDigits)
+	require.Equal(t, []string{"v*", "release/*"}, config.GitTagPatterns)
+	require.Equal(t, []string{"iphoneos", "iphonesimulator"}, config.SDKFilter)
+
+	input.BuildVersionOffsetComponent = -1
+	_, err = NewUpdater(parser, export.NewExporter(mocks.NewFactory(t)), log.NewLogger()).ProcessConfig()
+	require.EqualError(t, err, "build version offset component (-1) cannot be negative")
+
+	input.BuildVersionOffsetComponent = 0
+	input.BuildShortVersionSource = BuildShortVersionSourceGitTag
+	input.GitTagBumpPatch = true
+	input.BuildShortVersionBump = ShortVersionBumpPatch
+	_, err = NewUpdater(parser, export.NewExporter(mocks.NewFactory(t)), log.NewLogger()).ProcessConfig()
+	require.EqualError(t, err, "git tag patch bump cannot be combined with the patch version number bump, as both would bump the version of the git tag")
+}
+
 func Test_incrementBuildVersion(t *testing.T) {
 	logger := log.NewLogger()
 	tests := []struct {
 		name         string
 		buildVersion string
 		offset       int64
+		component    int
 		want         string
 		wantErr      bool
 	}{
@@ -66,8 +143,60 @@ func Test_incrementBuildVersion(t *testing.T) {
 			wantErr:      false,
 		},
 		{
-			name:         "non-numeric build version with non-zero offset",
+			name:         "dotted build version with non-zero offset",
+			buildVersion: "1.2.3.4",
+			offset:       3,
+			want:         "1.2.3.7",
+			wantErr:      false,
+		},
+		{
+			name:         "dotted build version with the offset applied to a component",
+			buildVersion: "1.2.3.4",
+			offset:       3,
+			component:    2,
+			want:         "1.5.3.4",
+			wantErr:      false,
+		},
+		{
+			name:         "dotted build version padding is kept",
+			buildVersion: "1.2.3.045",
+			offset:       5,
+			want:         "1.2.3.050",
+			wantErr:      false,
+		},
+		{
+			name:         "dotted build version component grows",
+			buildVersion: "1.2.3.99",
+			offset:       1,
+			want:         "1.2.3.100",
+			wantErr:      false,
+		},
+		{
+			name:         "dotted build version component out of range",
 			buildVersion: "1.2.3.4",
+			offset:       1,
+			component:    5,
+			want:         "",
+			wantErr:      true,
+		},
+		{
+			name:         "dotted build version component above the limit",
+			buildVersion: "1.2147483647",
+			offset:       1,
+			want:         "",
+			wantErr:      true,
+		},
+		{
+			name:         "numeric build version component out of range",
+			buildVersion: "42",
+			offset:       1,
+			component:    2,
+			want:         "",
+			wantErr:      true,
+		},
+		{
+			name:         "non-numeric build version with non-zero offset",
+			buildVersion: "1.2.3-beta",
 			offset:       3,
 			want:         "",
 			wantErr:      true,
@@ -75,7 +204,7 @@ func Test_incrementBuildVersion(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, gotErr := incrementBuildVersion(logger, tt.buildVersion, tt.offset)
+			got, gotErr := incrementBuildVersion(logger, tt.buildVersion, tt.offset, tt.component)
 			if tt.wantErr {
 				require.Error(t, gotErr)
 			} else {
@@ -86,3 +215,1730 @@ func Test_incrementBuildVersion(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdater_updateVersionNumbersInProject_xcconfig(t *testing.T) {
+	projectDir := copyTestProject(t)
+	replaceInFile(t, filepath.Join(projectDir, "Example.xcodeproj/project.pbxproj"), map[string]string{
+		"\t\t\t\tCURRENT_PROJECT_VERSION = 1;\n": "",
+		"\t\t\t\tMARKETING_VERSION = 1.0;\n":     "",
+	})
+	writeFile(t, filepath.Join(projectDir, "Example/Config.xcconfig"), `#include "Versions.xcconfig"
+INFOPLIST_FILE = Example/Example-Static-Info.plist
+`)
+	writeFile(t, filepath.Join(projectDir, "Example/Versions.xcconfig"), `// Versions
+CURRENT_PROJECT_VERSION = 1 // build number
+MARKETING_VERSION = 1.0;
+`)
+
+	helper, err := projectmanager.NewProjectHelper(filepath.Join(projectDir, "Example.xcodeproj"), "Example-Static", "")
+	require.NoError(t, err)
+
+	_, err = newTestUpdater().updateVersionNumbersInProject(helper, "Example-Static", "", "42", "2.0.0", buildSettingUpdateOptions{})
+	require.NoError(t, err)
+
+	require.Equal(t, `// Versions
+CURRENT_PROJECT_VERSION = 42 // build number
+MARKETING_VERSION = 2.0.0;
+`, readFile(t, filepath.Join(projectDir, "Example/Versions.xcconfig")))
+	require.NotContains(t, readFile(t, filepath.Join(projectDir, "Example.xcodeproj/project.pbxproj")), "CURRENT_PROJECT_VERSION = 42")
+}
+
+func TestUpdater_updateVersionNumbersInProject_projectLevel(t *testing.T) {
+	tests := []struct {
+		name                 string
+		overrideProjectLevel bool
+		wantProjectVersion   string
+		wantTargetVersion    interface{}
+	}{
+		{
+			name:                 "update project-level settings",
+			overrideProjectLevel: false,
+			wantProjectVersion:   "42",
+			wantTargetVersion:    nil,
+		},
+		{
+			name:                 "override project-level settings",
+			overrideProjectLevel: true,
+			wantProjectVersion:   "5",
+			wantTargetVersion:    "42",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			projectDir := copyTestProject(t)
+			replaceInFile(t, filepath.Join(projectDir, "Example.xcodeproj/project.pbxproj"), map[string]string{
+				"\t\t\t\tCURRENT_PROJECT_VERSION = 1;\n":   "",
+				"\t\t\t\tALWAYS_SEARCH_USER_PATHS = NO;\n": "\t\t\t\tALWAYS_SEARCH_USER_PATHS = NO;\n\t\t\t\tCURRENT_PROJECT_VERSION = 5;\n",
+			})
+			projectPath := filepath.Join(projectDir, "Example.xcodeproj")
+
+			helper, err := projectmanager.NewProjectHelper(projectPath, "Example-Static", "")
+			require.NoError(t, err)
+
+			_, err = newTestUpdater().updateVersionNumbersInProject(helper, "Example-Static", "Release", "42", "", buildSettingUpdateOptions{OverrideProjectLevel: tt.overrideProjectLevel})
+			require.NoError(t, err)
+
+			helper, err = projectmanager.NewProjectHelper(projectPath, "Example-Static", "")
+			require.NoError(t, err)
+
+			projectConfig := findBuildConfiguration(helper.XcProj.Proj.BuildConfigurationList, "Release")
+			require.Equal(t, tt.wantProjectVersion, projectConfig.BuildSettings["CURRENT_PROJECT_VERSION"])
+
+			target, ok := helper.XcProj.Proj.TargetByName("Example-Static")
+			require.True(t, ok)
+			targetConfig := findBuildConfiguration(target.BuildConfigurationList, "Release")
+			require.Equal(t, tt.wantTargetVersion, targetConfig.BuildSettings["CURRENT_PROJECT_VERSION"])
+		})
+	}
+}
+
+func Test_generatesInfoPlist(t *testing.T) {
+	projectDir := copyTestProject(t)
+	replaceInFile(t, filepath.Join(projectDir, "Example.xcodeproj/project.pbxproj"), map[string]string{
+		"\t\t\t\tGENERATE_INFOPLIST_FILE = NO;\n":  "",
+		"\t\t\t\tALWAYS_SEARCH_USER_PATHS = NO;\n": "\t\t\t\tALWAYS_SEARCH_USER_PATHS = NO;\n\t\t\t\tGENERATE_INFOPLIST_FILE = YES;\n",
+	})
+
+	helper, err := projectmanager.NewProjectHelper(filepath.Join(projectDir, "Example.xcodeproj"), "Example-Static", "")
+	require.NoError(t, err)
+
+	generated, err := newTestUpdater().generatesInfoPlist(helper, "Example-Static", "")
+	require.NoError(t, err)
+	require.True(t, generated)
+}
+
+func TestUpdater_updateVersionNumbersInProject_conditionalVariants(t *testing.T) {
+	projectDir := copyTestProject(t)
+	replaceInFile(t, filepath.Join(projectDir, "Example.xcodeproj/project.pbxproj"), map[string]string{
+		"\t\t\t\tCURRENT_PROJECT_VERSION = 1;\n": "",
+	})
+	writeFile(t, filepath.Join(projectDir, "Example/Config.xcconfig"), `INFOPLIST_FILE = Example/Example-Static-Info.plist
+CURRENT_PROJECT_VERSION = 1
+CURRENT_PROJECT_VERSION[sdk=macosx*] = 1
+CURRENT_PROJECT_VERSION[sdk=iphoneos*] = 1
+CURRENT_PROJECT_VERSION[arch=arm64] = 1
+`)
+
+	helper, err := projectmanager.NewProjectHelper(filepath.Join(projectDir, "Example.xcodeproj"), "Example-Static", "")
+	require.NoError(t, err)
+
+	_, err = newTestUpdater().updateVersionNumbersInProject(helper, "Example-Static", "", "42", "", buildSettingUpdateOptions{SDKFilter: []string{"macosx*"}})
+	require.NoError(t, err)
+
+	require.Equal(t, `INFOPLIST_FILE = Example/Example-Static-Info.plist
+CURRENT_PROJECT_VERSION = 42
+CURRENT_PROJECT_VERSION[sdk=macosx*] = 42
+CURRENT_PROJECT_VERSION[sdk=iphoneos*] = 1
+CURRENT_PROJECT_VERSION[arch=arm64] = 42
+`, readFile(t, filepath.Join(projectDir, "Example/Config.xcconfig")))
+}
+
+func TestUpdater_updateVersionNumbersInProject_referenceChain(t *testing.T) {
+	projectDir := copyTestProject(t)
+	replaceInFile(t, filepath.Join(projectDir, "Example.xcodeproj/project.pbxproj"), map[string]string{
+		"\t\t\t\tCURRENT_PROJECT_VERSION = 1;\n": "\t\t\t\tCURRENT_PROJECT_VERSION = \"$(APP_BUILD)\";\n",
+		"\t\t\t\tMARKETING_VERSION = 1.0;\n":     "\t\t\t\tMARKETING_VERSION = \"$(APP_VERSION)\";\n",
+	})
+	writeFile(t, filepath.Join(projectDir, "Example/Config.xcconfig"), `INFOPLIST_FILE = Example/Example-Static-Info.plist
+APP_VERSION = 1.0
+APP_BUILD = 1
+`)
+
+	pbxprojPath := filepath.Join(projectDir, "Example.xcodeproj/project.pbxproj")
+	originalPbxproj := readFile(t, pbxprojPath)
+
+	helper, err := projectmanager.NewProjectHelper(filepath.Join(projectDir, "Example.xcodeproj"), "Example-Static", "")
+	require.NoError(t, err)
+
+	updatedFiles, err := newTestUpdater().updateVersionNumbersInProject(helper, "Example-Static", "", "42", "2.0.0", buildSettingUpdateOptions{})
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(projectDir, "Example/Config.xcconfig")}, updatedFiles)
+
+	require.Equal(t, `INFOPLIST_FILE = Example/Example-Static-Info.plist
+APP_VERSION = 2.0.0
+APP_BUILD = 42
+`, readFile(t, filepath.Join(projectDir, "Example/Config.xcconfig")))
+	require.Equal(t, originalPbxproj, readFile(t, pbxprojPath))
+}
+
+func TestUpdater_updateVersionNumbersInInfoPlist_buildSettingReferences(t *testing.T) {
+	projectDir := copyTestProject(t)
+	infoPlistPath := filepath.Join(projectDir, "Example/Example-Static-Info.plist")
+	replaceInFile(t, infoPlistPath, map[string]string{
+		"<string>19876</string>":  "<string>$(CURRENT_PROJECT_VERSION)</string>",
+		"<string>12.5.5</string>": "<string>${APP_VERSION}</string>",
+	})
+	originalInfoPlist := readFile(t, infoPlistPath)
+
+	projectPath := filepath.Join(projectDir, "Example.xcodeproj")
+	helper, err := projectmanager.NewProjectHelper(projectPath, "Example-Static", "")
+	require.NoError(t, err)
+
+	_, err = newTestUpdater().updateVersionNumbersInInfoPlist(helper, "Example-Static", "Example-Static", "", "42", "2.0.0", buildSettingUpdateOptions{})
+	require.NoError(t, err)
+
+	require.Equal(t, originalInfoPlist, readFile(t, infoPlistPath))
+
+	helper, err = projectmanager.NewProjectHelper(projectPath, "Example-Static", "")
+	require.NoError(t, err)
+
+	for _, configuration := range []string{"Debug", "Release"} {
+		settings, err := newTestUpdater().targetBuildSettings(helper, "Example-Static", configuration)
+		require.NoError(t, err)
+
+		bundleVersion, err := settings.value("CURRENT_PROJECT_VERSION")
+		require.NoError(t, err)
+		require.Equal(t, "42", bundleVersion)
+
+		shortVersion, err := settings.value("APP_VERSION")
+		require.NoError(t, err)
+		require.Equal(t, "2.0.0", shortVersion)
+	}
+}
+
+func TestUpdater_Run_hybridInfoPlist(t *testing.T) {
+	tests := []struct {
+		name                  string
+		removePlistVersion    bool
+		unresolvablePlistPath bool
+		wantPlistVersion      interface{}
+	}{
+		{
+			name:               "static Info.plist overrides the generated version",
+			removePlistVersion: false,
+			wantPlistVersion:   "42",
+		},
+		{
+			name:               "generated version takes effect",
+			removePlistVersion: true,
+			wantPlistVersion:   nil,
+		},
+		{
+			name:                  "unresolvable static Info.plist path is ignored",
+			unresolvablePlistPath: true,
+			wantPlistVersion:      "7654",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			projectDir := copyTestProject(t)
+			infoPlistPath := filepath.Join(projectDir, "Example/Info.plist")
+			if tt.removePlistVersion {
+				replaceInFile(t, infoPlistPath, map[string]string{
+					"\t<key>CFBundleVersion</key>\n\t<string>7654</string>\n": "",
+				})
+			}
+			originalInfoPlist := readFile(t, infoPlistPath)
+
+			projectPath := filepath.Join(projectDir, "Example.xcodeproj")
+			if tt.unresolvablePlistPath {
+				replaceInFile(t, filepath.Join(projectPath, "project.pbxproj"), map[string]string{
+					`"INFOPLIST_FILE" = "$(SRCROOT)/Example/Info.plist";`: `"INFOPLIST_FILE" = "$(UNDEFINED_DIR)/Example/Info.plist";`,
+				})
+			}
+			_, err := newTestUpdater().Run(Config{
+				ProjectPath:               projectPath,
+				Scheme:                    "Example",
+				Target:                    "Example",
+				BuildVersion:              "42",
+				ForceBuildVersionDecrease: true,
+			})
+			require.NoError(t, err)
+
+			infoPlist, _, err := xcodeproj.ReadPlistFile(infoPlistPath)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantPlistVersion, infoPlist["CFBundleVersion"])
+			if tt.removePlistVersion || tt.unresolvablePlistPath {
+				require.Equal(t, originalInfoPlist, readFile(t, infoPlistPath))
+			}
+
+			helper, err := projectmanager.NewProjectHelper(projectPath, "Example", "")
+			require.NoError(t, err)
+
+			settings, err := newTestUpdater().targetBuildSettings(helper, "Example", "Release")
+			require.NoError(t, err)
+
+			projectVersion, err := settings.value("CURRENT_PROJECT_VERSION")
+			require.NoError(t, err)
+			require.Equal(t, "42", projectVersion)
+		})
+	}
+}
+
+func TestUpdater_Run_perConfigurationInfoPlist(t *testing.T) {
+	projectDir := copyTestProject(t)
+	releaseInfoPlistPath := filepath.Join(projectDir, "Example/Example-Static-Info.plist")
+	debugInfoPlistPath := filepath.Join(projectDir, "Example/Example-Static-Debug-Info.plist")
+	writeFile(t, debugInfoPlistPath, readFile(t, releaseInfoPlistPath))
+
+	projectPath := filepath.Join(projectDir, "Example.xcodeproj")
+	replaceInFile(t, filepath.Join(projectPath, "project.pbxproj"), map[string]string{
+		"31FFC9562B6D396C00B356FD /* Debug */ = {\n\t\t\tisa = XCBuildConfiguration;\n\t\t\tbaseConfigurationReference = 316869AC2C369E4E00D9196F /* Config.xcconfig */;\n\t\t\tbuildSettings = {\n": "31FFC9562B6D396C00B356FD /* Debug */ = {\n\t\t\tisa = XCBuildConfiguration;\n\t\t\tbaseConfigurationReference = 316869AC2C369E4E00D9196F /* Config.xcconfig */;\n\t\t\tbuildSettings = {\n\t\t\t\tINFOPLIST_FILE = \"Example/Example-Static-Debug-Info.plist\";\n",
+	})
+
+	_, err := newTestUpdater().Run(Config{
+		ProjectPath:               projectPath,
+		Scheme:                    "Example-Static",
+		Target:                    "Example-Static",
+		BuildVersion:              "42",
+		BuildShortVersionString:   "2.0.0",
+		ForceBuildVersionDecrease: true,
+	})
+	require.NoError(t, err)
+
+	for _, infoPlistPath := range []string{debugInfoPlistPath, releaseInfoPlistPath} {
+		infoPlist, _, err := xcodeproj.ReadPlistFile(infoPlistPath)
+		require.NoError(t, err)
+		require.Equal(t, "42", infoPlist["CFBundleVersion"])
+		require.Equal(t, "2.0.0", infoPlist["CFBundleShortVersionString"])
+	}
+}
+
+// targetDependencyEmbedding embeds the Example-Static app into the Example app through a target dependency
+var targetDependencyEmbedding = map[string]string{
+	"\t\t\tdependencies = (\n\t\t\t);\n\t\t\tname = Example;": "\t\t\tdependencies = (\n\t\t\t\t31FFC9F02B6D38E000B356FD /* PBXTargetDependency */,\n\t\t\t);\n\t\t\tname = Example;",
+	"/* Begin PBXTargetDependency section */\n":               "/* Begin PBXTargetDependency section */\n\t\t31FFC9F02B6D38E000B356FD /* PBXTargetDependency */ = {\n\t\t\tisa = PBXTargetDependency;\n\t\t\ttarget = 31FFC94B2B6D396C00B356FD /* Example-Static */;\n\t\t};\n",
+}
+
+// copyFilesPhaseEmbedding embeds the Example-Static app into the Example app through a Copy Files build phase, without a target dependency
+var copyFilesPhaseEmbedding = map[string]string{
+	"\t\t\t\t31FFC9162B6D38DC00B356FD /* Resources */,\n": "\t\t\t\t31FFC9162B6D38DC00B356FD /* Resources */,\n\t\t\t\t31FFC9F12B6D38E000B356FD /* Embed Login Items */,\n",
+	"/* Begin PBXBuildFile section */\n":                  "/* Begin PBXBuildFile section */\n\t\t31FFC9F22B6D38E000B356FD /* Example-Static.app in Embed Login Items */ = {isa = PBXBuildFile; fileRef = 31FFC9582B6D396C00B356FD /* Example-Static.app */; };\n\t\t31FFC9F12B6D38E000B356FD /* Embed Login Items */ = {\n\t\t\tisa = PBXCopyFilesBuildPhase;\n\t\t\tbuildActionMask = 2147483647;\n\t\t\tdstPath = Contents/Library/LoginItems;\n\t\t\tdstSubfolderSpec = 1;\n\t\t\tfiles = (\n\t\t\t\t31FFC9F22B6D38E000B356FD /* Example-Static.app in Embed Login Items */,\n\t\t\t);\n\t\t\tname = \"Embed Login Items\";\n\t\t\trunOnlyForDeploymentPostprocessing = 0;\n\t\t};\n",
+}
+
+func TestUpdater_Run_embeddedTargets(t *testing.T) {
+	tests := []struct {
+		name                  string
+		embedding             map[string]string
+		updateEmbeddedTargets bool
+		wantEmbeddedVersion   string
+	}{
+		{
+			name:                  "only the main target is updated by default",
+			embedding:             targetDependencyEmbedding,
+			updateEmbeddedTargets: false,
+			wantEmbeddedVersion:   "19876",
+		},
+		{
+			name:                  "targets embedded through a target dependency are updated",
+			embedding:             targetDependencyEmbedding,
+			updateEmbeddedTargets: true,
+			wantEmbeddedVersion:   "42",
+		},
+		{
+			name:                  "targets embedded through a Copy Files build phase are updated",
+			embedding:             copyFilesPhaseEmbedding,
+			updateEmbeddedTargets: true,
+			wantEmbeddedVersion:   "42",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			projectDir := copyTestProject(t)
+			projectPath := filepath.Join(projectDir, "Example.xcodeproj")
+			replaceInFile(t, filepath.Join(projectPath, "project.pbxproj"), tt.embedding)
+
+			_, err := newTestUpdater().Run(Config{
+				ProjectPath:               projectPath,
+				Scheme:                    "Example",
+				BuildVersion:              "42",
+				UpdateEmbeddedTargets:     tt.updateEmbeddedTargets,
+				ForceBuildVersionDecrease: true,
+			})
+			require.NoError(t, err)
+
+			helper, err := projectmanager.NewProjectHelper(projectPath, "Example", "")
+			require.NoError(t, err)
+
+			settings, err := newTestUpdater().targetBuildSettings(helper, "Example", "Release")
+			require.NoError(t, err)
+
+			projectVersion, err := settings.value("CURRENT_PROJECT_VERSION")
+			require.NoError(t, err)
+			require.Equal(t, "42", projectVersion)
+
+			infoPlist, _, err := xcodeproj.ReadPlistFile(filepath.Join(projectDir, "Example/Example-Static-Info.plist"))
+			require.NoError(t, err)
+			require.Equal(t, tt.wantEmbeddedVersion, infoPlist["CFBundleVersion"])
+		})
+	}
+}
+
+// referenceProxyEmbedding embeds the Other-Static app of the Other/Example.xcodeproj sub-project into the Example app
+// through a Copy Files build phase and a PBXReferenceProxy
+var referenceProxyEmbedding = map[string]string{
+	"\t\t\t\t31FFC9162B6D38DC00B356FD /* Resources */,\n": "\t\t\t\t31FFC9162B6D38DC00B356FD /* Resources */,\n\t\t\t\t31FFC9F12B6D38E000B356FD /* Embed Login Items */,\n",
+	"/* Begin PBXBuildFile section */\n":                  "/* Begin PBXBuildFile section */\n\t\t31FFC9F22B6D38E000B356FD /* Other-Static.app in Embed Login Items */ = {isa = PBXBuildFile; fileRef = 31FFC9F32B6D38E000B356FD /* Other-Static.app */; };\n\t\t31FFC9F12B6D38E000B356FD /* Embed Login Items */ = {\n\t\t\tisa = PBXCopyFilesBuildPhase;\n\t\t\tbuildActionMask = 2147483647;\n\t\t\tdstPath = Contents/Library/LoginItems;\n\t\t\tdstSubfolderSpec = 1;\n\t\t\tfiles = (\n\t\t\t\t31FFC9F22B6D38E000B356FD /* Other-Static.app in Embed Login Items */,\n\t\t\t);\n\t\t\tname = \"Embed Login Items\";\n\t\t\trunOnlyForDeploymentPostprocessing = 0;\n\t\t};\n\t\t31FFC9F32B6D38E000B356FD /* Other-Static.app */ = {\n\t\t\tisa = PBXReferenceProxy;\n\t\t\tfileType = wrapper.application;\n\t\t\tpath = \"Other-Static.app\";\n\t\t\tremoteRef = 31FFC9F42B6D38E000B356FD /* PBXContainerItemProxy */;\n\t\t\tsourceTree = BUILT_PRODUCTS_DIR;\n\t\t};\n\t\t31FFC9F42B6D38E000B356FD /* PBXContainerItemProxy */ = {\n\t\t\tisa = PBXContainerItemProxy;\n\t\t\tcontainerPortal = 31FFC9F52B6D38E000B356FD /* Example.xcodeproj */;\n\t\t\tproxyType = 2;\n\t\t\tremoteGlobalIDString = 31FFC9582B6D396C00B356FD;\n\t\t\tremoteInfo = \"Example-Static\";\n\t\t};\n",
+	"/* Begin PBXFileReference section */\n":              "/* Begin PBXFileReference section */\n\t\t31FFC9F52B6D38E000B356FD /* Example.xcodeproj */ = {isa = PBXFileReference; lastKnownFileType = \"wrapper.pb-project\"; path = Other/Example.xcodeproj; sourceTree = SOURCE_ROOT; };\n",
+}
+
+// builtProductEmbedding embeds the Other-Static app, built by another project of the workspace, into the Example app
+// through a Copy Files build phase, without a target dependency
+var builtProductEmbedding = map[string]string{
+	"\t\t\t\t31FFC9162B6D38DC00B356FD /* Resources */,\n": "\t\t\t\t31FFC9162B6D38DC00B356FD /* Resources */,\n\t\t\t\t31FFC9F12B6D38E000B356FD /* Embed Login Items */,\n",
+	"/* Begin PBXBuildFile section */\n":                  "/* Begin PBXBuildFile section */\n\t\t31FFC9F22B6D38E000B356FD /* Other-Static.app in Embed Login Items */ = {isa = PBXBuildFile; fileRef = 31FFC9F32B6D38E000B356FD /* Other-Static.app */; };\n\t\t31FFC9F12B6D38E000B356FD /* Embed Login Items */ = {\n\t\t\tisa = PBXCopyFilesBuildPhase;\n\t\t\tbuildActionMask = 2147483647;\n\t\t\tdstPath = Contents/Library/LoginItems;\n\t\t\tdstSubfolderSpec = 1;\n\t\t\tfiles = (\n\t\t\t\t31FFC9F22B6D38E000B356FD /* Other-Static.app in Embed Login Items */,\n\t\t\t);\n\t\t\tname = \"Embed Login Items\";\n\t\t\trunOnlyForDeploymentPostprocessing = 0;\n\t\t};\n",
+	"/* Begin PBXFileReference section */\n":              "/* Begin PBXFileReference section */\n\t\t31FFC9F32B6D38E000B356FD /* Other-Static.app */ = {isa = PBXFileReference; lastKnownFileType = wrapper.application; path = \"Other-Static.app\"; sourceTree = BUILT_PRODUCTS_DIR; };\n",
+}
+
+func TestUpdater_Run_crossProjectEmbeddedTargets(t *testing.T) {
+	tests := []struct {
+		name      string
+		embedding map[string]string
+	}{
+		{
+			name:      "product of a sub-project",
+			embedding: referenceProxyEmbedding,
+		},
+		{
+			name:      "product built by another project of the workspace",
+			embedding: builtProductEmbedding,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workspaceDir := copyTestWorkspace(t)
+
+			// The Other/Example.xcodeproj project builds the Example-Static target's product as Other-Static.app. Its app
+			// target is renamed, so that the Example scheme's target is unique in the workspace.
+			otherDir := filepath.Join(workspaceDir, "Other")
+			copyDir(t, "../testdata/workspace/Example", otherDir)
+			replaceInFile(t, filepath.Join(otherDir, "Example.xcodeproj/project.pbxproj"), map[string]string{
+				`path = "Example-Static.app"; sourceTree = BUILT_PRODUCTS_DIR;`: `path = "Other-Static.app"; sourceTree = BUILT_PRODUCTS_DIR;`,
+				"\t\t\tname = Example;\n": "\t\t\tname = OtherApp;\n",
+			})
+			replaceInFile(t, filepath.Join(workspaceDir, "Example.xcworkspace/contents.xcworkspacedata"), map[string]string{
+				"</Workspace>": "   <FileRef\n      location = \"group:Other/Example.xcodeproj\">\n   </FileRef>\n</Workspace>",
+			})
+			replaceInFile(t, filepath.Join(workspaceDir, "Example.xcodeproj/project.pbxproj"), tt.embedding)
+
+			_, err := newTestUpdater().Run(Config{
+				ProjectPath:               filepath.Join(workspaceDir, "Example.xcworkspace"),
+				Scheme:                    "Example",
+				BuildVersion:              "42",
+				UpdateEmbeddedTargets:     true,
+				ForceBuildVersionDecrease: true,
+			})
+			require.NoError(t, err)
+
+			infoPlist, _, err := xcodeproj.ReadPlistFile(filepath.Join(otherDir, "Example/Example-Static-Info.plist"))
+			require.NoError(t, err)
+			require.Equal(t, "42", infoPlist["CFBundleVersion"])
+
+			infoPlist, _, err = xcodeproj.ReadPlistFile(filepath.Join(workspaceDir, "Example/Example-Static-Info.plist"))
+			require.NoError(t, err)
+			require.Equal(t, "19876", infoPlist["CFBundleVersion"])
+		})
+	}
+}
+
+func TestUpdater_Run_versionConsistencyCheck(t *testing.T) {
+	tests := []struct {
+		name                    string
+		updateEmbeddedTargets   bool
+		versionConsistencyCheck string
+		wantErr                 string
+	}{
+		{
+			name:                    "mismatching embedded target fails the step",
+			versionConsistencyCheck: VersionConsistencyCheckFail,
+			wantErr:                 "Example-Static  Release        19876 (expected 42)  12.5.5 (expected 2.0.0)",
+		},
+		{
+			name:                    "mismatching embedded target is only reported",
+			versionConsistencyCheck: VersionConsistencyCheckWarn,
+		},
+		{
+			name:                    "updated embedded target passes the check",
+			updateEmbeddedTargets:   true,
+			versionConsistencyCheck: VersionConsistencyCheckFail,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			projectDir := copyTestProject(t)
+			projectPath := filepath.Join(projectDir, "Example.xcodeproj")
+			replaceInFile(t, filepath.Join(projectPath, "project.pbxproj"), targetDependencyEmbedding)
+
+			_, err := newTestUpdater().Run(Config{
+				ProjectPath:               projectPath,
+				Scheme:                    "Example",
+				BuildVersion:              "42",
+				BuildShortVersionString:   "2.0.0",
+				UpdateEmbeddedTargets:     tt.updateEmbeddedTargets,
+				VersionConsistencyCheck:   tt.versionConsistencyCheck,
+				ForceBuildVersionDecrease: true,
+			})
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestUpdater_Run_assertMode(t *testing.T) {
+	tests := []struct {
+		name                    string
+		buildVersion            string
+		buildShortVersionString string
+		wantErr                 string
+	}{
+		{
+			name:                    "matching version numbers",
+			buildVersion:            "19876",
+			buildShortVersionString: "12.5.5",
+		},
+		{
+			name:         "version number is not checked if it is not provided",
+			buildVersion: "19876",
+		},
+		{
+			name:                    "mismatching version numbers",
+			buildVersion:            "42",
+			buildShortVersionString: "12.5.5",
+			wantErr:                 "Example-Static  Debug          19876 (expected 42)  12.5.5",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			projectDir := copyTestProject(t)
+			projectPath := filepath.Join(projectDir, "Example.xcodeproj")
+			pbxprojPath := filepath.Join(projectPath, "project.pbxproj")
+			infoPlistPath := filepath.Join(projectDir, "Example/Example-Static-Info.plist")
+			originalPbxproj := readFile(t, pbxprojPath)
+			originalInfoPlist := readFile(t, infoPlistPath)
+
+			result, err := newTestUpdater().Run(Config{
+				Mode:                    ModeAssert,
+				ProjectPath:             projectPath,
+				Scheme:                  "Example-Static",
+				BuildVersion:            tt.buildVersion,
+				BuildShortVersionString: tt.buildShortVersionString,
+			})
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.wantErr)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tt.buildVersion, result.BuildVersion)
+				require.Equal(t, tt.buildShortVersionString, result.ShortVersion)
+			}
+
+			require.Equal(t, originalPbxproj, readFile(t, pbxprojPath))
+			require.Equal(t, originalInfoPlist, readFile(t, infoPlistPath))
+		})
+	}
+}
+
+func TestUpdater_Run_inventoryMode(t *testing.T) {
+	projectDir := copyTestProject(t)
+	projectPath := filepath.Join(projectDir, "Example.xcodeproj")
+	pbxprojPath := filepath.Join(projectPath, "project.pbxproj")
+	originalPbxproj := readFile(t, pbxprojPath)
+
+	result, err := newTestUpdater().Run(Config{
+		Mode:         ModeInventory,
+		ProjectPath:  projectPath,
+		Scheme:       "Example-Static",
+		BuildVersion: "42",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "19876", result.BuildVersion)
+	require.Equal(t, "12.5.5", result.ShortVersion)
+	require.Equal(t, originalPbxproj, readFile(t, pbxprojPath))
+
+	var inventory versionInventory
+	require.NoError(t, json.Unmarshal([]byte(readFile(t, result.InventoryPath)), &inventory))
+	require.Equal(t, "Example-Static", inventory.MainTarget.Target)
+
+	versions := map[string]targetVersions{}
+	for _, targetVersions := range inventory.Targets {
+		versions[targetVersions.Target+"/"+targetVersions.Configuration] = targetVersions
+	}
+
+	require.Equal(t, versionValue{
+		Value:    "19876",
+		File:     filepath.Join(projectDir, "Example/Example-Static-Info.plist"),
+		Location: filepath.Join(projectDir, "Example/Example-Static-Info.plist"),
+	}, versions["Example-Static/Debug"].BuildVersion)
+	require.Equal(t, versionValue{
+		Value:    "7654",
+		File:     filepath.Join(projectDir, "Example/Info.plist"),
+		Location: filepath.Join(projectDir, "Example/Info.plist"),
+	}, versions["Example/Release"].BuildVersion)
+	require.Equal(t, versionValue{
+		Value:    "1",
+		File:     pbxprojPath,
+		Location: "Release configuration of the target",
+	}, versions["ExampleTests/Release"].BuildVersion)
+}
+
+func TestUpdater_Run_inventoryMode_workspace(t *testing.T) {
+	workspaceDir := copyTestWorkspace(t)
+	writeFile(t, filepath.Join(workspaceDir, "Example/Info.plist"), "not a property list")
+
+	result, err := newTestUpdater().Run(Config{
+		Mode:         ModeInventory,
+		ProjectPath:  filepath.Join(workspaceDir, "Example.xcworkspace"),
+		Scheme:       "Example-Static",
+		BuildVersion: "42",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "19876", result.BuildVersion)
+	require.Equal(t, "12.5.5", result.ShortVersion)
+
+	var inventory versionInventory
+	require.NoError(t, json.Unmarshal([]byte(readFile(t, result.InventoryPath)), &inventory))
+
+	versions := map[string]targetVersions{}
+	for _, targetVersions := range inventory.Targets {
+		versions[targetVersions.Target+"/"+targetVersions.Configuration] = targetVersions
+	}
+
+	require.Equal(t, filepath.Join(workspaceDir, "Example.xcodeproj"), versions["Example-Static/Release"].Project)
+	require.Equal(t, "19876", versions["Example-Static/Release"].BuildVersion.Value)
+	require.Equal(t, "1", versions["ExampleTests/Release"].BuildVersion.Value)
+	require.Empty(t, versions["ExampleTests/Release"].Error)
+
+	// The unreadable Info.plist of the Example target is recorded, the other targets are still listed.
+	require.Empty(t, versions["Example/Release"].BuildVersion.Value)
+	require.NotEmpty(t, versions["Example/Release"].Error)
+}
+
+func TestUpdater_Run_dryRun(t *testing.T) {
+	projectDir := copyTestProject(t)
+	originalFiles := readDir(t, projectDir)
+
+	logger := newRecordingLogger()
+	updater := newTestUpdater()
+	updater.logger = logger
+
+	_, err := updater.Run(Config{
+		ProjectPath:               filepath.Join(projectDir, "Example.xcodeproj"),
+		Scheme:                    "Example",
+		BuildVersion:              "42",
+		BuildShortVersionString:   "2.0.0",
+		DryRun:                    true,
+		ForceBuildVersionDecrease: true,
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, originalFiles, readDir(t, projectDir))
+
+	output := logger.output.String()
+	require.Contains(t, output, "Dry run: the following changes would be made")
+	require.Contains(t, output, "--- "+filepath.Join(projectDir, "Example.xcodeproj/project.pbxproj"))
+	require.Contains(t, output, "-\t\t\"CURRENT_PROJECT_VERSION\" = 9999;\n+\t\t\"CURRENT_PROJECT_VERSION\" = 42;")
+	require.Contains(t, output, "--- "+filepath.Join(projectDir, "Example/Info.plist"))
+	require.Contains(t, output, "-\t<string>7654</string>")
+	require.Regexp(t, `Example\s+Debug\s+7654 -> 42\s+1\.1111 -> 2\.0\.0`, output)
+	require.Regexp(t, `Example\s+Release\s+7654 -> 42\s+1\.1111 -> 2\.0\.0`, output)
+}
+
+func TestUpdater_Run_incrementExistingBuildVersion(t *testing.T) {
+	tests := []struct {
+		name                        string
+		scheme                      string
+		currentBuildVersion         string
+		buildVersionOffset          int64
+		buildVersionOffsetComponent int
+		embedding                   map[string]string
+		wantBuildVersion            string
+		wantErr                     string
+	}{
+		{
+			name:             "the current build number is incremented by 1 by default",
+			scheme:           "Example-Static",
+			wantBuildVersion: "19877",
+		},
+		{
+			name:               "the current build number is incremented by the offset",
+			scheme:             "Example-Static",
+			buildVersionOffset: 10,
+			wantBuildVersion:   "19886",
+		},
+		{
+			name:                "the last component of a dotted current build number is incremented",
+			scheme:              "Example-Static",
+			currentBuildVersion: "1.2.09",
+			wantBuildVersion:    "1.2.10",
+		},
+		{
+			name:                        "the offset component of a dotted current build number is incremented",
+			scheme:                      "Example-Static",
+			currentBuildVersion:         "1.2.3",
+			buildVersionOffset:          5,
+			buildVersionOffsetComponent: 2,
+			wantBuildVersion:            "1.7.3",
+		},
+		{
+			name:      "the targets have different build numbers",
+			scheme:    "Example",
+			embedding: targetDependencyEmbedding,
+			wantErr:   "the targets have different build numbers",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			projectDir := copyTestProject(t)
+			projectPath := filepath.Join(projectDir, "Example.xcodeproj")
+			if tt.embedding != nil {
+				replaceInFile(t, filepath.Join(projectPath, "project.pbxproj"), tt.embedding)
+			}
+			if tt.currentBuildVersion != "" {
+				replaceInFile(t, filepath.Join(projectDir, "Example/Example-Static-Info.plist"), map[string]string{
+					"<string>19876</string>": "<string>" + tt.currentBuildVersion + "</string>",
+				})
+			}
+
+			result, err := newTestUpdater().Run(Config{
+				ProjectPath:                 projectPath,
+				Scheme:                      tt.scheme,
+				BuildVersionMode:            BuildVersionModeIncrementExisting,
+				BuildVersion:                "1",
+				BuildVersionOffset:          tt.buildVersionOffset,
+				BuildVersionOffsetComponent: tt.buildVersionOffsetComponent,
+				UpdateEmbeddedTargets:       tt.embedding != nil,
+			})
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantBuildVersion, result.BuildVersion)
+
+			infoPlist, _, err := xcodeproj.ReadPlistFile(filepath.Join(projectDir, "Example/Example-Static-Info.plist"))
+			require.NoError(t, err)
+			require.Equal(t, tt.wantBuildVersion, infoPlist["CFBundleVersion"])
+		})
+	}
+}
+
+func TestUpdater_Run_shortVersionBump(t *testing.T) {
+	projectDir := copyTestProject(t)
+	projectPath := filepath.Join(projectDir, "Example.xcodeproj")
+
+	result, err := newTestUpdater().Run(Config{
+		ProjectPath:               projectPath,
+		Scheme:                    "Example-Static",
+		BuildVersion:              "42",
+		BuildShortVersionBump:     ShortVersionBumpMinor,
+		ForceBuildVersionDecrease: true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "12.6.0", result.ShortVersion)
+	require.Equal(t, "12.5.5", result.PreviousShortVersion)
+
+	infoPlist, _, err := xcodeproj.ReadPlistFile(filepath.Join(projectDir, "Example/Example-Static-Info.plist"))
+	require.NoError(t, err)
+	require.Equal(t, "12.6.0", infoPlist["CFBundleShortVersionString"])
+}
+
+func Test_bumpVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		version string
+		bump    string
+		want    string
+		wantErr bool
+	}{
+		{name: "major", version: "1.2.3", bump: ShortVersionBumpMajor, want: "2.0.0"},
+		{name: "minor", version: "1.2.3", bump: ShortVersionBumpMinor, want: "1.3.0"},
+		{name: "patch", version: "1.2.3", bump: ShortVersionBumpPatch, want: "1.2.4"},
+		{name: "number of components is kept", version: "1.2", bump: ShortVersionBumpMajor, want: "2.0"},
+		{name: "missing component is added", version: "1", bump: ShortVersionBumpPatch, want: "1.0.1"},
+		{name: "leading zeros are dropped", version: "1.09", bump: ShortVersionBumpMinor, want: "1.10"},
+		{name: "empty version", version: "", bump: ShortVersionBumpPatch, wantErr: true},
+		{name: "too many components", version: "1.2.3.4", bump: ShortVersionBumpPatch, wantErr: true},
+		{name: "non-integer component", version: "1.2-beta", bump: ShortVersionBumpPatch, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := bumpVersion(tt.version, tt.bump)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestUpdater_Run_calendarVersioning(t *testing.T) {
+	projectDir := copyTestProject(t)
+	projectPath := filepath.Join(projectDir, "Example.xcodeproj")
+
+	updater := newTestUpdater()
+	updater.now = func() time.Time {
+		return time.Date(2024, time.March, 15, 10, 0, 0, 0, time.UTC)
+	}
+
+	result, err := updater.Run(Config{
+		ProjectPath:        projectPath,
+		Scheme:             "Example-Static",
+		VersioningStrategy: VersioningStrategyCalVer,
+		BuildVersion:       "42",
+	})
+	require.NoError(t, err)
+	require.Equal(t, "2024.3.1", result.ShortVersion)
+	require.Equal(t, "2024031501", result.BuildVersion)
+	require.Equal(t, "12.5.5", result.PreviousShortVersion)
+
+	infoPlist, _, err := xcodeproj.ReadPlistFile(filepath.Join(projectDir, "Example/Example-Static-Info.plist"))
+	require.NoError(t, err)
+	require.Equal(t, result.BuildVersion, infoPlist["CFBundleVersion"])
+	require.Equal(t, result.ShortVersion, infoPlist["CFBundleShortVersionString"])
+}
+
+func Test_nextCalendarVersion(t *testing.T) {
+	now := time.Date(2024, time.March, 15, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		current string
+		want    string
+		wantErr bool
+	}{
+		{name: "same month", current: "2024.3.4", want: "2024.3.5"},
+		{name: "same month with zero-padded month", current: "2024.03.4", want: "2024.3.5"},
+		{name: "new month", current: "2024.2.7", want: "2024.3.1"},
+		{name: "not a calendar version", current: "1.2.3", want: "2024.3.1"},
+		{name: "no current version", current: "", want: "2024.3.1"},
+		{name: "current version is in the future", current: "2024.4.1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := nextCalendarVersion(tt.current, now)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_nextCalendarBuildVersion(t *testing.T) {
+	now := time.Date(2024, time.March, 15, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		current string
+		want    string
+		wantErr bool
+	}{
+		{name: "same day", current: "2024031503", want: "2024031504"},
+		{name: "new day", current: "2024031409", want: "2024031501"},
+		{name: "sequential build number", current: "19876", want: "2024031501"},
+		{name: "non-numeric build number", current: "1.2.3", want: "2024031501"},
+		{name: "too many builds on the same day", current: "2024031599", wantErr: true},
+		{name: "current build number is greater", current: "99999999999", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := nextCalendarBuildVersion(tt.current, now)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestUpdater_Run_gitBuildVersionSource(t *testing.T) {
+	tests := []struct {
+		name               string
+		source             string
+		baseRef            string
+		buildVersionOffset int64
+		shallow            bool
+		wantBuildVersion   string
+		wantErr            string
+	}{
+		{
+			name:             "commit count",
+			source:           BuildVersionSourceGitCommitCount,
+			wantBuildVersion: "3",
+		},
+		{
+			name:               "commit count with offset",
+			source:             BuildVersionSourceGitCommitCount,
+			buildVersionOffset: 100,
+			wantBuildVersion:   "103",
+		},
+		{
+			name:             "commit count since the base ref",
+			source:           BuildVersionSourceGitCommitCount,
+			baseRef:          "base",
+			wantBuildVersion: "2",
+		},
+		{
+			name:    "unknown base ref",
+			source:  BuildVersionSourceGitCommitCount,
+			baseRef: "unknown",
+			wantErr: "the base ref (unknown) is not found",
+		},
+		{
+			name:    "commit count in a shallow clone",
+			source:  BuildVersionSourceGitCommitCount,
+			shallow: true,
+			wantErr: "is a shallow clone",
+		},
+		{
+			name:             "commit timestamp",
+			source:           BuildVersionSourceGitCommitTimestamp,
+			wantBuildVersion: "20240315.1032",
+		},
+		{
+			name:               "commit timestamp with offset",
+			source:             BuildVersionSourceGitCommitTimestamp,
+			buildVersionOffset: 2,
+			wantBuildVersion:   "20240315.1034",
+		},
+		{
+			name:             "commit timestamp in a shallow clone",
+			source:           BuildVersionSourceGitCommitTimestamp,
+			shallow:          true,
+			wantBuildVersion: "20240315.1032",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			projectDir := copyTestProject(t)
+			initGitRepository(t, projectDir)
+			runGit(t, projectDir, "tag", "base")
+			runGit(t, projectDir, "commit", "--quiet", "--allow-empty", "-m", "Second commit")
+			runGit(t, projectDir, "commit", "--quiet", "--allow-empty", "-m", "Third commit")
+
+			if tt.shallow {
+				cloneDir := filepath.Join(t.TempDir(), "Example")
+				runGit(t, projectDir, "clone", "--quiet", "--depth", "1", "file://"+projectDir, cloneDir)
+				projectDir = cloneDir
+			}
+
+			result, err := newTestUpdater().Run(Config{
+				ProjectPath:               filepath.Join(projectDir, "Example.xcodeproj"),
+				Scheme:                    "Example-Static",
+				BuildVersionSource:        tt.source,
+				GitBaseRef:                tt.baseRef,
+				BuildVersion:              "1",
+				BuildVersionOffset:        tt.buildVersionOffset,
+				VersionValidation:         VersionValidationStrict,
+				ForceBuildVersionDecrease: true,
+			})
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantBuildVersion, result.BuildVersion)
+		})
+	}
+}
+
+func TestUpdater_Run_gitTagShortVersion(t *testing.T) {
+	tests := []struct {
+		name             string
+		headTag          string
+		patterns         []string
+		bumpPatch        bool
+		stripPreRelease  bool
+		bump             string
+		wantShortVersion string
+		wantPrevious     string
+		wantErr          string
+	}{
+		{
+			name:             "version of the nearest tag",
+			wantShortVersion: "1.4.2",
+		},
+		{
+			name:             "patch is bumped if HEAD is ahead of the tag",
+			bumpPatch:        true,
+			wantShortVersion: "1.4.3",
+		},
+		{
+			name:             "patch is not bumped if HEAD is tagged",
+			headTag:          "v1.4.3",
+			bumpPatch:        true,
+			wantShortVersion: "1.4.3",
+		},
+		{
+			name:             "version of the tag is bumped",
+			bump:             ShortVersionBumpMinor,
+			wantShortVersion: "1.5.0",
+			wantPrevious:     "1.4.2",
+		},
+		{
+			name:     "pre-release tag",
+			headTag:  "release/1.5.0-beta.1+build.5",
+			patterns: []string{"release/*"},
+			wantErr:  "has pre-release or build metadata",
+		},
+		{
+			name:             "pre-release is stripped",
+			headTag:          "release/1.5.0-beta.1+build.5",
+			patterns:         []string{"release/*"},
+			stripPreRelease:  true,
+			wantShortVersion: "1.5.0",
+		},
+		{
+			name:     "no matching tag",
+			patterns: []string{"app/*"},
+			wantErr:  "no tag matching app/* is reachable from HEAD",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			projectDir := copyTestProject(t)
+			initGitRepository(t, projectDir)
+			runGit(t, projectDir, "tag", "v1.4.2")
+			runGit(t, projectDir, "commit", "--quiet", "--allow-empty", "-m", "Second commit")
+			if tt.headTag != "" {
+				runGit(t, projectDir, "tag", tt.headTag)
+			}
+
+			result, err := newTestUpdater().Run(Config{
+				ProjectPath:               filepath.Join(projectDir, "Example.xcodeproj"),
+				Scheme:                    "Example-Static",
+				BuildVersion:              "42",
+				BuildShortVersionSource:   BuildShortVersionSourceGitTag,
+				GitTagPatterns:            tt.patterns,
+				GitTagBumpPatch:           tt.bumpPatch,
+				GitTagStripPreRelease:     tt.stripPreRelease,
+				BuildShortVersionBump:     tt.bump,
+				ForceBuildVersionDecrease: true,
+			})
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantShortVersion, result.ShortVersion)
+			require.Equal(t, tt.wantPrevious, result.PreviousShortVersion)
+
+			infoPlist, _, err := xcodeproj.ReadPlistFile(filepath.Join(projectDir, "Example/Example-Static-Info.plist"))
+			require.NoError(t, err)
+			require.Equal(t, tt.wantShortVersion, infoPlist["CFBundleShortVersionString"])
+		})
+	}
+}
+
+func Test_parseTagVersion(t *testing.T) {
+	tests := []struct {
+		tag     string
+		want    tagVersion
+		wantErr bool
+	}{
+		{tag: "v1.4.2", want: tagVersion{Version: "1.4.2"}},
+		{tag: "release/1.4", want: tagVersion{Version: "1.4"}},
+		{tag: "2", want: tagVersion{Version: "2"}},
+		{tag: "v1.04.2", want: tagVersion{Version: "1.4.2"}},
+		{tag: "v1.4.2-beta.1+build.5", want: tagVersion{Version: "1.4.2", PreRelease: "beta.1", Metadata: "build.5"}},
+		{tag: "v1.4.2+20240315", want: tagVersion{Version: "1.4.2", Metadata: "20240315"}},
+		{tag: "v1.4.2.3", wantErr: true},
+		{tag: "latest", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			got, err := parseTagVersion(tt.tag)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestUpdater_Run_conventionalCommitsBump(t *testing.T) {
+	tests := []struct {
+		name             string
+		messages         []string
+		source           string
+		wantShortVersion string
+		wantBump         string
+		wantCommits      int
+	}{
+		{
+			name:             "fix bumps the patch",
+			messages:         []string{"fix: crash on launch", "docs: update the README"},
+			wantShortVersion: "12.5.6",
+			wantBump:         ShortVersionBumpPatch,
+			wantCommits:      1,
+		},
+		{
+			name:             "feature bumps the minor",
+			messages:         []string{"fix(login): typo", "feat(login): add SSO"},
+			wantShortVersion: "12.6.0",
+			wantBump:         ShortVersionBumpMinor,
+			wantCommits:      2,
+		},
+		{
+			name:             "breaking change bumps the major",
+			messages:         []string{"feat: add SSO", "refactor!: drop iOS 14"},
+			wantShortVersion: "13.0.0",
+			wantBump:         ShortVersionBumpMajor,
+			wantCommits:      2,
+		},
+		{
+			name:             "breaking change footer bumps the major",
+			messages:         []string{"fix: crash on launch\n\nBREAKING CHANGE: the settings are reset"},
+			wantShortVersion: "13.0.0",
+			wantBump:         ShortVersionBumpMajor,
+			wantCommits:      1,
+		},
+		{
+			name:             "other commits do not bump",
+			messages:         []string{"chore: update dependencies", "Merge branch 'main'"},
+			wantShortVersion: "12.5.5",
+			wantBump:         ShortVersionBumpNone,
+		},
+		{
+			name:             "version of the git tag is bumped",
+			messages:         []string{"feat: add SSO"},
+			source:           BuildShortVersionSourceGitTag,
+			wantShortVersion: "1.5.0",
+			wantBump:         ShortVersionBumpMinor,
+			wantCommits:      1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			projectDir := copyTestProject(t)
+			initGitRepository(t, projectDir)
+			runGit(t, projectDir, "tag", "v1.4.2")
+			for _, message := range tt.messages {
+				runGit(t, projectDir, "commit", "--quiet", "--allow-empty", "-m", message)
+			}
+
+			result, err := newTestUpdater().Run(Config{
+				ProjectPath:               filepath.Join(projectDir, "Example.xcodeproj"),
+				Scheme:                    "Example-Static",
+				BuildVersion:              "42",
+				BuildShortVersionSource:   tt.source,
+				BuildShortVersionBump:     ShortVersionBumpConventionalCommits,
+				ForceBuildVersionDecrease: true,
+			})
+			require.NoError(t, err)
+
+			infoPlist, _, err := xcodeproj.ReadPlistFile(filepath.Join(projectDir, "Example/Example-Static-Info.plist"))
+			require.NoError(t, err)
+			require.Equal(t, tt.wantShortVersion, infoPlist["CFBundleShortVersionString"])
+
+			var report versionBumpReport
+			require.NoError(t, json.Unmarshal([]byte(readFile(t, result.VersionBumpReportPath)), &report))
+			require.Equal(t, "v1.4.2", report.Tag)
+			require.Equal(t, tt.wantBump, report.Bump)
+			require.Len(t, report.Commits, tt.wantCommits)
+		})
+	}
+}
+
+func TestUpdater_Run_formulaBuildVersion(t *testing.T) {
+	tests := []struct {
+		name                    string
+		formula                 string
+		buildShortVersionString string
+		buildVersion            string
+		buildVersionOffset      int64
+		wantBuildVersion        string
+		wantErr                 string
+	}{
+		{
+			name:                    "packed",
+			buildShortVersionString: "1.4.2",
+			buildVersion:            "30",
+			buildVersionOffset:      7,
+			wantBuildVersion:        "1040237",
+		},
+		{
+			name:             "packed with the current version number",
+			buildVersion:     "37",
+			wantBuildVersion: "12050537",
+		},
+		{
+			name:                    "dotted",
+			formula:                 BuildVersionFormulaDotted,
+			buildShortVersionString: "19876.1",
+			buildVersion:            "37",
+			wantBuildVersion:        "19876.1.37",
+		},
+		{
+			name:                    "build number is not increasing",
+			buildShortVersionString: "0.1",
+			buildVersion:            "37",
+			wantErr:                 "the build number (10037) is not greater than the current build number (19876)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			projectDir := copyTestProject(t)
+			projectPath := filepath.Join(projectDir, "Example.xcodeproj")
+
+			result, err := newTestUpdater().Run(Config{
+				ProjectPath:             projectPath,
+				Scheme:                  "Example-Static",
+				BuildVersionMode:        BuildVersionModeFormula,
+				BuildVersionFormula:     tt.formula,
+				BuildVersion:            tt.buildVersion,
+				BuildVersionOffset:      tt.buildVersionOffset,
+				BuildShortVersionString: tt.buildShortVersionString,
+			})
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantBuildVersion, result.BuildVersion)
+		})
+	}
+}
+
+func Test_packedFormulaBuildVersion(t *testing.T) {
+	tests := []struct {
+		name           string
+		shortVersion   string
+		ciBuildVersion string
+		digits         []int
+		want           string
+		wantErr        bool
+	}{
+		{name: "two digits", shortVersion: "1.4.2", ciBuildVersion: "37", digits: []int{2, 2, 2}, want: "1040237"},
+		{name: "custom digits", shortVersion: "1.4.2", ciBuildVersion: "37", digits: []int{1, 1, 3}, want: "142037"},
+		{name: "missing components", shortVersion: "2", ciBuildVersion: "5", digits: []int{2, 2, 2}, want: "2000005"},
+		{name: "zero major component", shortVersion: "0.1", ciBuildVersion: "37", digits: []int{2, 2, 2}, want: "10037"},
+		{name: "component does not fit", shortVersion: "1.4.2", ciBuildVersion: "137", digits: []int{2, 2, 2}, wantErr: true},
+		{name: "largest build number", shortVersion: "2.147.483", ciBuildVersion: "647", digits: []int{3, 3, 3}, want: "2147483647"},
+		{name: "build number is too large", shortVersion: "1.4.2", ciBuildVersion: "37", digits: []int{3, 3, 4}, wantErr: true},
+		{name: "wrong number of digits", shortVersion: "1.4.2", ciBuildVersion: "37", digits: []int{2, 2}, wantErr: true},
+		{name: "non-numeric build number", shortVersion: "1.4.2", ciBuildVersion: "37a", digits: []int{2, 2, 2}, wantErr: true},
+		{name: "invalid version number", shortVersion: "1.4.2-beta", ciBuildVersion: "37", digits: []int{2, 2, 2}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := packedFormulaBuildVersion(tt.shortVersion, tt.ciBuildVersion, tt.digits)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_dottedFormulaBuildVersion(t *testing.T) {
+	got, err := dottedFormulaBuildVersion("1.4", "37")
+	require.NoError(t, err)
+	require.Equal(t, "1.4.37", got)
+
+	_, err = dottedFormulaBuildVersion("1.4.2", "37")
+	require.Error(t, err)
+}
+
+func Test_compareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "1.2.3", b: "1.2.3", want: 0},
+		{a: "1.2", b: "1.2.0", want: 0},
+		{a: "1.10", b: "1.9", want: 1},
+		{a: "2", b: "1.99.99", want: 1},
+		{a: "1.2.3", b: "1.2.4", want: -1},
+		{a: "99", b: "100", want: -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			got, err := compareVersions(tt.a, tt.b)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestUpdater_Run_versionTemplates(t *testing.T) {
+	t.Setenv("BITRISE_BUILD_NUMBER", "37")
+
+	tests := []struct {
+		name                    string
+		buildVersion            string
+		buildShortVersionString string
+		wantBuildVersion        string
+		wantShortVersion        map[string]string
+		wantErr                 string
+	}{
+		{
+			name:                    "templates are rendered",
+			buildVersion:            "{offset_build}",
+			buildShortVersionString: "{current_marketing}.{git_count}",
+			wantBuildVersion:        "40",
+			wantShortVersion:        map[string]string{"Debug": "1.0.1", "Release": "1.0.1"},
+		},
+		{
+			name:                    "offset is not applied to the rendered build number",
+			buildVersion:            "{ci_build}",
+			buildShortVersionString: "2.0",
+			wantBuildVersion:        "37",
+			wantShortVersion:        map[string]string{"Debug": "2.0", "Release": "2.0"},
+		},
+		{
+			name:                    "configuration placeholder is rendered per configuration",
+			buildVersion:            "{ci_build}",
+			buildShortVersionString: "2.0-{configuration}",
+			wantBuildVersion:        "37",
+			wantShortVersion:        map[string]string{"Debug": "2.0-Debug", "Release": "2.0-Release"},
+		},
+		{
+			name:         "unknown placeholder",
+			buildVersion: "{build}",
+			wantErr:      "unknown placeholder {build} in {build}, the supported placeholders are: {ci_build}",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			projectDir := copyTestProject(t)
+			projectPath := filepath.Join(projectDir, "Example.xcodeproj")
+			initGitRepository(t, projectDir)
+
+			result, err := newTestUpdater().Run(Config{
+				ProjectPath:             projectPath,
+				Scheme:                  "Example",
+				Target:                  "ExampleTests",
+				BuildVersion:            tt.buildVersion,
+				BuildVersionOffset:      3,
+				BuildShortVersionString: tt.buildShortVersionString,
+			})
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantBuildVersion, result.BuildVersion)
+			require.Equal(t, tt.wantShortVersion["Release"], result.ShortVersion)
+
+			helper, err := projectmanager.NewProjectHelper(projectPath, "Example", "")
+			require.NoError(t, err)
+
+			for configuration, wantShortVersion := range tt.wantShortVersion {
+				settings, err := newTestUpdater().targetBuildSettings(helper, "ExampleTests", configuration)
+				require.NoError(t, err)
+
+				projectVersion, err := settings.value("CURRENT_PROJECT_VERSION")
+				require.NoError(t, err)
+				require.Equal(t, tt.wantBuildVersion, projectVersion)
+
+				marketingVersion, err := settings.value("MARKETING_VERSION")
+				require.NoError(t, err)
+				require.Equal(t, wantShortVersion, marketingVersion)
+			}
+		})
+	}
+}
+
+func Test_versionTemplate_render(t *testing.T) {
+	template := versionTemplate{
+		updater: newTestUpdater(),
+		now:     time.Date(2024, time.March, 5, 9, 7, 0, 0, time.UTC),
+		values:  map[string]string{},
+	}
+
+	got, err := template.render("{date:YYYYMMDD}.{date:HHmm}")
+	require.NoError(t, err)
+	require.Equal(t, "20240305.0907", got)
+
+	got, err = template.render("{date:YY}-{configuration}")
+	require.NoError(t, err)
+	require.Equal(t, "24-{configuration}", got)
+
+	_, err = template.render("{date:}")
+	require.Error(t, err)
+
+	_, err = template.render("{date:YYYY-MM-DD}")
+	require.EqualError(t, err, "invalid date format YYYY-MM-DD in {date:YYYY-MM-DD}, the supported fields are: YYYY, YY, MM, DD, HH, mm, ss")
+
+	_, err = template.render("{date:Monday}")
+	require.Error(t, err)
+}
+
+func TestUpdater_Run_versionValidation(t *testing.T) {
+	tests := []struct {
+		name                    string
+		validation              string
+		buildVersionMode        string
+		buildVersion            string
+		buildVersionOffset      int64
+		buildShortVersionString string
+		wantBuildVersion        string
+		wantShortVersion        string
+		wantErr                 string
+	}{
+		{
+			name:                    "valid version numbers",
+			validation:              VersionValidationStrict,
+			buildVersion:            "42",
+			buildShortVersionString: "1.2.3",
+			wantBuildVersion:        "42",
+			wantShortVersion:        "1.2.3",
+		},
+		{
+			name:                    "invalid version number",
+			validation:              VersionValidationStrict,
+			buildVersion:            "42",
+			buildShortVersionString: "1.2.3-beta",
+			wantErr:                 "invalid version number of the Debug configuration of the Example-Static target: 1.2.3-beta is not period-separated non-negative integers",
+		},
+		{
+			name:         "too many build number components",
+			validation:   VersionValidationStrict,
+			buildVersion: "1.2.3.4",
+			wantErr:      "1.2.3.4 has 4 components, at most 3 are allowed",
+		},
+		{
+			name:                    "version numbers are sanitized",
+			validation:              VersionValidationSanitize,
+			buildVersion:            " 42 ",
+			buildShortVersionString: "v1.2.3-beta.1+5",
+			wantBuildVersion:        "42",
+			wantShortVersion:        "1.2.3",
+		},
+		{
+			name:                    "version number is sanitized before the build number formula",
+			validation:              VersionValidationSanitize,
+			buildVersionMode:        BuildVersionModeFormula,
+			buildVersion:            "37",
+			buildShortVersionString: "v1.4.2",
+			wantBuildVersion:        "1040237",
+			wantShortVersion:        "1.4.2",
+		},
+		{
+			name:                    "build number is sanitized before the offset",
+			validation:              VersionValidationSanitize,
+			buildVersionMode:        BuildVersionModeOffset,
+			buildVersion:            "v42",
+			buildVersionOffset:      19900,
+			buildShortVersionString: "12.6.0",
+			wantBuildVersion:        "19942",
+			wantShortVersion:        "12.6.0",
+		},
+		{
+			name:         "sanitized build number is still invalid",
+			validation:   VersionValidationSanitize,
+			buildVersion: "build42",
+			wantErr:      "build42 is not period-separated non-negative integers",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			projectDir := copyTestProject(t)
+			projectPath := filepath.Join(projectDir, "Example.xcodeproj")
+			infoPlistPath := filepath.Join(projectDir, "Example/Example-Static-Info.plist")
+			originalInfoPlist := readFile(t, infoPlistPath)
+
+			buildVersionMode := tt.buildVersionMode
+			if buildVersionMode == "" {
+				buildVersionMode = BuildVersionModeSet
+			}
+
+			result, err := newTestUpdater().Run(Config{
+				ProjectPath:               projectPath,
+				Scheme:                    "Example-Static",
+				BuildVersionMode:          buildVersionMode,
+				BuildVersion:              tt.buildVersion,
+				BuildVersionOffset:        tt.buildVersionOffset,
+				BuildShortVersionString:   tt.buildShortVersionString,
+				VersionValidation:         tt.validation,
+				ForceBuildVersionDecrease: true,
+			})
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.wantErr)
+				require.Equal(t, originalInfoPlist, readFile(t, infoPlistPath))
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.wantBuildVersion, result.BuildVersion)
+			require.Equal(t, tt.wantShortVersion, result.ShortVersion)
+
+			infoPlist, _, err := xcodeproj.ReadPlistFile(infoPlistPath)
+			require.NoError(t, err)
+			require.Equal(t, tt.wantBuildVersion, infoPlist["CFBundleVersion"])
+			require.Equal(t, tt.wantShortVersion, infoPlist["CFBundleShortVersionString"])
+		})
+	}
+}
+
+func Test_validateVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		wantErr bool
+	}{
+		{version: "1"},
+		{version: "1.2.3"},
+		{version: "1.02.003"},
+		{version: "2147483647"},
+		{version: "", wantErr: true},
+		{version: "1.2.3.4", wantErr: true},
+		{version: "1..2", wantErr: true},
+		{version: "v1.2", wantErr: true},
+		{version: "1.2-beta", wantErr: true},
+		{version: "2147483648", wantErr: true},
+		{version: "1234567890.12345678", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			err := validateVersion(tt.version, maxBundleVersionLength)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestUpdater_Run_buildVersionDecrease(t *testing.T) {
+	tests := []struct {
+		name                string
+		buildVersion        string
+		minimumBuildVersion string
+		force               bool
+		wantErr             string
+	}{
+		{
+			name:         "higher build number",
+			buildVersion: "19877",
+		},
+		{
+			name:         "same build number",
+			buildVersion: "19876",
+		},
+		{
+			name:         "lower build number",
+			buildVersion: "9999",
+			wantErr:      "- Example-Static (Debug): 9999 is lower than the current build number 19876",
+		},
+		{
+			name:         "build numbers are compared as integers",
+			buildVersion: "100000",
+		},
+		{
+			name:         "forced lower build number",
+			buildVersion: "42",
+			force:        true,
+		},
+		{
+			name:                "lower than the minimum build number",
+			buildVersion:        "20000",
+			minimumBuildVersion: "20001",
+			wantErr:             "- Debug: 20000 is lower than the minimum build number 20001",
+		},
+		{
+			name:                "dotted build numbers are compared by component",
+			buildVersion:        "19877.10",
+			minimumBuildVersion: "19877.9",
+		},
+		{
+			name:                "invalid minimum build number",
+			buildVersion:        "19877",
+			minimumBuildVersion: "latest",
+			wantErr:             "the build number (19877) cannot be compared to the minimum build number (latest)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			projectDir := copyTestProject(t)
+			projectPath := filepath.Join(projectDir, "Example.xcodeproj")
+			infoPlistPath := filepath.Join(projectDir, "Example/Example-Static-Info.plist")
+			originalInfoPlist := readFile(t, infoPlistPath)
+
+			result, err := newTestUpdater().Run(Config{
+				ProjectPath:               projectPath,
+				Scheme:                    "Example-Static",
+				BuildVersion:              tt.buildVersion,
+				MinimumBuildVersion:       tt.minimumBuildVersion,
+				ForceBuildVersionDecrease: tt.force,
+			})
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.wantErr)
+				require.Equal(t, originalInfoPlist, readFile(t, infoPlistPath))
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.buildVersion, result.BuildVersion)
+
+			infoPlist, _, err := xcodeproj.ReadPlistFile(infoPlistPath)
+			require.NoError(t, err)
+			require.Equal(t, tt.buildVersion, infoPlist["CFBundleVersion"])
+		})
+	}
+}
+
+func Test_buildSettingReference(t *testing.T) {
+	tests := []struct {
+		value   interface{}
+		want    string
+		wantRef bool
+	}{
+		{value: "$(MARKETING_VERSION)", want: "MARKETING_VERSION", wantRef: true},
+		{value: "${CURRENT_PROJECT_VERSION}", want: "CURRENT_PROJECT_VERSION", wantRef: true},
+		{value: "$APP_BUILD", want: "APP_BUILD", wantRef: true},
+		{value: "1.0.0", want: "", wantRef: false},
+		{value: "$(MARKETING_VERSION)-beta", want: "", wantRef: false},
+		{value: "$(PRODUCT_NAME:lower)", want: "", wantRef: false},
+		{value: 42, want: "", wantRef: false},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%v", tt.value), func(t *testing.T) {
+			got, ok := buildSettingReference(tt.value)
+			require.Equal(t, tt.wantRef, ok)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_matchesSDKFilter(t *testing.T) {
+	tests := []struct {
+		name      string
+		condition string
+		sdkFilter []string
+		want      bool
+	}{
+		{name: "no filter", condition: "[sdk=macosx*]", sdkFilter: nil, want: true},
+		{name: "same pattern", condition: "[sdk=macosx*]", sdkFilter: []string{"macosx*"}, want: true},
+		{name: "concrete SDK", condition: "[sdk=iphoneos*]", sdkFilter: []string{"iphoneos17.2"}, want: true},
+		{name: "pattern filter", condition: "[sdk=iphonesimulator17.2]", sdkFilter: []string{"iphone*"}, want: true},
+		{name: "different SDK", condition: "[sdk=iphoneos*]", sdkFilter: []string{"macosx*"}, want: false},
+		{name: "one of the SDKs", condition: "[sdk=iphoneos*][arch=arm64]", sdkFilter: []string{"macosx*", "iphoneos*"}, want: true},
+		{name: "arch only", condition: "[arch=arm64]", sdkFilter: []string{"macosx*"}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.want, matchesSDKFilter(tt.condition, tt.sdkFilter))
+		})
+	}
+}
+
+func newTestUpdater() Updater {
+	envRepository := env.NewRepository()
+	return NewUpdater(stepconf.NewInputParser(envRepository), export.NewExporter(command.NewFactory(envRepository)), log.NewLogger())
+}
+
+// recordingLogger is a logger that also records the printed messages.
+type recordingLogger struct {
+	log.Logger
+	output *strings.Builder
+}
+
+func newRecordingLogger() recordingLogger {
+	return recordingLogger{Logger: log.NewLogger(), output: &strings.Builder{}}
+}
+
+func (l recordingLogger) Printf(format string, v ...interface{}) {
+	l.Logger.Printf(format, v...)
+	l.output.WriteString(fmt.Sprintf(format, v...) + "\n")
+}
+
+// initGitRepository creates a git repository with a single commit in the directory.
+func initGitRepository(t *testing.T, dir string) {
+	runGit(t, dir, "init", "--quiet")
+	runGit(t, dir, "add", "-A")
+	runGit(t, dir, "commit", "--quiet", "-m", "Initial commit")
+}
+
+func runGit(t *testing.T, dir string, args ...string) {
+	cmd := exec.Command("git", append([]string{"-c", "user.name=Test", "-c", "user.email=test@example.com"}, args...)...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_COMMITTER_DATE=2024-03-15T10:32:00Z", "GIT_AUTHOR_DATE=2024-03-15T10:32:00Z")
+	output, err := cmd.CombinedOutput()
+	require.NoError(t, err, string(output))
+}
+
+func copyTestProject(t *testing.T) string {
+	dst := filepath.Join(t.TempDir(), "Example")
+	copyDir(t, "../testdata/project/Example", dst)
+	return dst
+}
+
+func copyTestWorkspace(t *testing.T) string {
+	dst := filepath.Join(t.TempDir(), "Example")
+	copyDir(t, "../testdata/workspace/Example", dst)
+	return dst
+}
+
+func copyDir(t *testing.T, src, dst string) {
+	src, err := filepath.Abs(src)
+	require.NoError(t, err)
+
+	err = filepath.WalkDir(src, func(pth string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, pth)
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			return os.MkdirAll(filepath.Join(dst, rel), 0755)
+		}
+
+		content, err := os.ReadFile(pth)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(filepath.Join(dst, rel), content, 0644)
+	})
+	require.NoError(t, err)
+}
+
+func readDir(t *testing.T, dir string) map[string]string {
+	files := map[string]string{}
+	err := filepath.WalkDir(dir, func(pth string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		files[pth] = readFile(t, pth)
+		return nil
+	})
+	require.NoError(t, err)
+
+	return files
+}
+
+func readFile(t *testing.T, pth string) string {
+	content, err := os.ReadFile(pth)
+	require.NoError(t, err)
+	return string(content)
+}
+
+func writeFile(t *testing.T, pth, content string) {
+	require.NoError(t, os.WriteFile(pth, []byte(content), 0644))
+}
+
+func replaceInFile(t *testing.T, pth string, replacements map[string]string) {
+	content := readFile(t, pth)
+	for old, new := range replacements {
+		if !strings.Contains(content, old) {
+			t.Fatalf("%q not found in %s", old, pth)
+		}
+		content = strings.ReplaceAll(content, old, new)
+	}
+	writeFile(t, pth, content)
+}
@@ -0,0 +1,163 @@
+package step
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bitrise-io/go-utils/v2/env"
+)
+
+const configurationPlaceholder = "{configuration}"
+
+// placeholderRegexp matches a template placeholder, like {ci_build} or {date:YYYYMMDD}.
+var placeholderRegexp = regexp.MustCompile(`\{([^{}]*)\}`)
+
+var supportedPlaceholders = []string{"{ci_build}", "{offset_build}", "{date:FORMAT}", "{git_count}", "{git_short_sha}", "{current_marketing}", configurationPlaceholder}
+
+var supportedDateFormatFields = []string{"YYYY", "YY", "MM", "DD", "HH", "mm", "ss"}
+
+// dateFormatRegexp matches a date format of the {date:FORMAT} placeholder, which can only contain the supported fields.
+var dateFormatRegexp = regexp.MustCompile(`^(` + strings.Join(supportedDateFormatFields, "|") + `)+$`)
+
+// dateFormatReplacer converts the date format of the {date:FORMAT} placeholder to a Go time layout.
+var dateFormatReplacer = strings.NewReplacer("YYYY", "2006", "YY", "06", "MM", "01", "DD", "02", "HH", "15", "mm", "04", "ss", "05")
+
+// versionTemplate renders the placeholders of the build and version number templates. The values are only calculated
+// if they are used, as some of them need the git history or the project's current version numbers.
+type versionTemplate struct {
+	updater Updater
+	targets []bundleTarget
+	config  Config
+	now     time.Time
+	values  map[string]string
+}
+
+func isVersionTemplate(value string) bool {
+	return placeholderRegexp.MatchString(value)
+}
+
+// render replaces the placeholders of the template, except {configuration}, which is replaced per build configuration.
+func (t *versionTemplate) render(template string) (string, error) {
+	for _, match := range placeholderRegexp.FindAllStringSubmatch(template, -1) {
+		if !isSupportedPlaceholder(match[1]) {
+			return "", fmt.Errorf("unknown placeholder %s in %s, the supported placeholders are: %s", match[0], template, strings.Join(supportedPlaceholders, ", "))
+		}
+		if format := strings.TrimPrefix(match[1], "date:"); format != match[1] && !dateFormatRegexp.MatchString(format) {
+			return "", fmt.Errorf("invalid date format %s in %s, the supported fields are: %s", format, template, strings.Join(supportedDateFormatFields, ", "))
+		}
+	}
+
+	var renderErr error
+	rendered := placeholderRegexp.ReplaceAllStringFunc(template, func(placeholder string) string {
+		if renderErr != nil || placeholder == configurationPlaceholder {
+			return placeholder
+		}
+
+		value, err := t.value(strings.Trim(placeholder, "{}"))
+		if err != nil {
+			renderErr = fmt.Errorf("failed to render the %s placeholder: %w", placeholder, err)
+		}
+		return value
+	})
+	if renderErr != nil {
+		return "", renderErr
+	}
+
+	return rendered, nil
+}
+
+func (t *versionTemplate) value(name string) (string, error) {
+	if value, ok := t.values[name]; ok {
+		return value, nil
+	}
+
+	var value string
+	var err error
+	repository := gitRepository{dir: filepath.Dir(t.config.ProjectPath)}
+
+	switch {
+	case name == "ci_build":
+		value = env.NewRepository().Get("BITRISE_BUILD_NUMBER")
+		if value == "" {
+			err = fmt.Errorf("BITRISE_BUILD_NUMBER is not set")
+		}
+	case name == "offset_build":
+		var ciBuild string
+		if ciBuild, err = t.value("ci_build"); err == nil {
+			value, err = incrementBuildVersion(t.updater.logger, ciBuild, t.config.BuildVersionOffset, t.config.BuildVersionOffsetComponent)
+		}
+	case strings.HasPrefix(name, "date:"):
+		value = t.now.Format(dateFormatReplacer.Replace(strings.TrimPrefix(name, "date:")))
+	case name == "git_count":
+		var count int64
+		if count, err = repository.commitCount(t.config.GitBaseRef); err == nil {
+			value = strconv.FormatInt(count, 10)
+		}
+	case name == "git_short_sha":
+		value, err = repository.run("rev-parse", "--short", "HEAD")
+	case name == "current_marketing":
+		var allVersions []targetVersions
+		if allVersions, err = t.updater.allTargetVersions(t.targets, t.config); err == nil {
+			value, err = currentVersion(allVersions, "version number", func(versions targetVersions) versionValue {
+				return versions.ShortVersion
+			})
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	t.values[name] = value
+	return value, nil
+}
+
+func isSupportedPlaceholder(name string) bool {
+	switch name {
+	case "ci_build", "offset_build", "git_count", "git_short_sha", "current_marketing", "configuration":
+		return true
+	}
+	return strings.HasPrefix(name, "date:") && len(name) > len("date:")
+}
+
+// renderVersionTemplates renders the build and version number inputs, if they are templates. A rendered build number is
+// used as-is: the offset is available through the {offset_build} placeholder.
+func (u Updater) renderVersionTemplates(targets []bundleTarget, config Config, now time.Time) (Config, error) {
+	template := versionTemplate{updater: u, targets: targets, config: config, now: now, values: map[string]string{}}
+
+	if isVersionTemplate(config.BuildShortVersionString) {
+		rendered, err := template.render(config.BuildShortVersionString)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid version number template: %w", err)
+		}
+
+		u.logger.Printf("Version number template %s rendered: %s", config.BuildShortVersionString, rendered)
+		config.BuildShortVersionString = rendered
+	}
+
+	if isVersionTemplate(config.BuildVersion) {
+		rendered, err := template.render(config.BuildVersion)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid build number template: %w", err)
+		}
+
+		u.logger.Printf("Build number template %s rendered: %s", config.BuildVersion, rendered)
+		config.BuildVersion = rendered
+		config.BuildVersionMode = BuildVersionModeSet
+		config.BuildVersionSource = BuildVersionSourceInput
+		config.BuildVersionOffset = 0
+	}
+
+	return config, nil
+}
+
+// configurationVersions replaces the {configuration} placeholder of the build and version numbers with the name of the
+// build configuration.
+func configurationVersions(config Config, configuration string) Config {
+	config.BuildVersion = strings.ReplaceAll(config.BuildVersion, configurationPlaceholder, configuration)
+	config.BuildShortVersionString = strings.ReplaceAll(config.BuildShortVersionString, configurationPlaceholder, configuration)
+	return config
+}
@@ -0,0 +1,111 @@
+package step
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// maxShortVersionLength is the maximum length of the CFBundleShortVersionString App Store Connect accepts.
+const maxShortVersionLength = 18
+
+// versionSuffixRegexp matches the pre-release and build metadata suffix of a semantic version, like -beta.1+5.
+var versionSuffixRegexp = regexp.MustCompile(`^([0-9.]+)[-+].*$`)
+
+// sanitizeVersionNumbers fixes the common mistakes of the build and version number inputs in the sanitize validation
+// mode (a v prefix, a pre-release or build metadata suffix), before the offset, the bump or the formula is applied.
+func (u Updater) sanitizeVersionNumbers(config Config) Config {
+	if config.VersionValidation != VersionValidationSanitize {
+		return config
+	}
+
+	config.BuildVersion = u.sanitizeVersion("build number", config.BuildVersion)
+	config.BuildShortVersionString = u.sanitizeVersion("version number", config.BuildShortVersionString)
+	return config
+}
+
+// validateVersionNumbers checks the resolved build and version numbers against the rules of App Store Connect before
+// anything is written: one to three period-separated non-negative integers, and a length limit.
+func (u Updater) validateVersionNumbers(targets []bundleTarget, config Config) error {
+	if config.VersionValidation == "" || config.VersionValidation == VersionValidationOff {
+		return nil
+	}
+
+	for _, target := range targets {
+		configurations, err := targetConfigurations(target.helper, target.name, config.Configuration)
+		if err != nil {
+			return err
+		}
+
+		for _, configuration := range configurations {
+			versions := configurationVersions(config, configuration)
+			if err := validateVersion(versions.BuildVersion, maxBundleVersionLength); err != nil {
+				return fmt.Errorf("invalid build number of the %s configuration of the %s target: %w", configuration, target.name, err)
+			}
+			if versions.BuildShortVersionString == "" {
+				continue
+			}
+			if err := validateVersion(versions.BuildShortVersionString, maxShortVersionLength); err != nil {
+				return fmt.Errorf("invalid version number of the %s configuration of the %s target: %w", configuration, target.name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateVersion checks if the version is one to three period-separated non-negative integers, not longer than the
+// maximum length.
+func validateVersion(version string, maxLength int) error {
+	if version == "" {
+		return fmt.Errorf("empty value")
+	}
+	if len(version) > maxLength {
+		return fmt.Errorf("%s is longer than %d characters", version, maxLength)
+	}
+
+	components := strings.Split(version, ".")
+	if len(components) > maxBundleVersionComponents {
+		return fmt.Errorf("%s has %d components, at most %d are allowed", version, len(components), maxBundleVersionComponents)
+	}
+	for _, component := range components {
+		if component == "" || strings.Trim(component, "0123456789") != "" {
+			return fmt.Errorf("%s is not period-separated non-negative integers", version)
+		}
+		if value, err := strconv.ParseUint(component, 10, 64); err != nil || value > maxBundleVersionComponentValue {
+			return fmt.Errorf("the %s component of %s is greater than %d", component, version, maxBundleVersionComponentValue)
+		}
+	}
+
+	return nil
+}
+
+// sanitizeVersion fixes the common mistakes of a version: surrounding whitespace, a v prefix, and a pre-release or build
+// metadata suffix. Every change is logged.
+func (u Updater) sanitizeVersion(name, version string) string {
+	if version == "" {
+		return version
+	}
+	if strings.Contains(version, configurationPlaceholder) {
+		u.logger.Warnf("The %s (%s) depends on the build configuration, it is only validated, not sanitized", name, version)
+		return version
+	}
+
+	sanitized := strings.TrimSpace(version)
+	if sanitized != version {
+		u.logger.Warnf("Trimming the whitespace around the %s (%q)", name, version)
+	}
+
+	if trimmed := strings.TrimLeft(sanitized, "vV"); trimmed != sanitized {
+		u.logger.Warnf("Dropping the v prefix of the %s (%s): %s", name, sanitized, trimmed)
+		sanitized = trimmed
+	}
+
+	if match := versionSuffixRegexp.FindStringSubmatch(sanitized); match != nil {
+		u.logger.Warnf("Dropping the pre-release or build metadata suffix of the %s (%s): %s", name, sanitized, match[1])
+		sanitized = match[1]
+	}
+
+	return sanitized
+}
@@ -0,0 +1,183 @@
+package step
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/bitrise-io/go-xcode/v2/autocodesign/projectmanager"
+	"github.com/bitrise-io/go-xcode/xcodeproject/serialized"
+)
+
+// versionValue is the effective value of a version number and the place it is defined at.
+type versionValue struct {
+	Value    string `json:"value"`
+	File     string `json:"file,omitempty"`
+	Location string `json:"location,omitempty"`
+}
+
+// targetVersions are the effective version numbers of a build configuration of a target.
+type targetVersions struct {
+	Project       string       `json:"project"`
+	Target        string       `json:"target"`
+	Configuration string       `json:"configuration"`
+	BuildVersion  versionValue `json:"build_version"`
+	ShortVersion  versionValue `json:"short_version"`
+	// Error is set in the version inventory if the version numbers could not be resolved.
+	Error string `json:"error,omitempty"`
+}
+
+// targetVersions resolves the version numbers the given configuration of the target is built with. It follows the same
+// paths as the update: the generated Info.plist (build settings), the static Info.plist and the hybrid of the two.
+func (u Updater) targetVersions(helper *projectmanager.ProjectHelper, schemeName, targetName, configuration string) (targetVersions, error) {
+	versions := targetVersions{Project: helper.XcProj.Path, Target: targetName, Configuration: configuration}
+
+	settings, err := u.targetBuildSettings(helper, targetName, configuration)
+	if err != nil {
+		return targetVersions{}, err
+	}
+
+	generated, err := u.generatesInfoPlist(helper, targetName, configuration)
+	if err != nil {
+		return targetVersions{}, err
+	}
+
+	var infoPlistPath string
+	if generated {
+		staticInfoPlistPath, hybrid, err := u.staticInfoPlistPath(helper, targetName, configuration)
+		if err != nil {
+			return targetVersions{}, err
+		}
+		if hybrid {
+			infoPlistPath = staticInfoPlistPath
+		}
+	} else if _, ok := settings.definition(infoPlistFileKey); ok {
+		// Targets without an Info.plist, like static libraries, do not have version numbers.
+		infoPlistPath, err = u.infoPlistPath(helper, schemeName, targetName, configuration)
+		if err != nil {
+			return targetVersions{}, err
+		}
+	}
+
+	var infoPlist serialized.Object
+	if infoPlistPath != "" {
+		infoPlist, _, err = u.files.readPlist(infoPlistPath)
+		if err != nil {
+			return targetVersions{}, err
+		}
+	}
+
+	versions.BuildVersion, err = effectiveVersion(settings, infoPlist, infoPlistPath, bundleVersionKey, projectVersionKey, generated)
+	if err != nil {
+		return targetVersions{}, err
+	}
+
+	versions.ShortVersion, err = effectiveVersion(settings, infoPlist, infoPlistPath, shortVersionKey, marketingVersionKey, generated)
+	if err != nil {
+		return targetVersions{}, err
+	}
+
+	return versions, nil
+}
+
+// effectiveVersion returns the value of the Info.plist key, or the value of the build setting if the Info.plist is
+// generated and the key is not overridden by a static Info.plist.
+func effectiveVersion(settings buildSettings, infoPlist serialized.Object, infoPlistPath, plistKey, buildSettingKey string, generated bool) (versionValue, error) {
+	rawValue, ok := infoPlist[plistKey]
+	if !ok {
+		if generated {
+			return buildSettingVersion(settings, buildSettingKey)
+		}
+		return versionValue{File: infoPlistPath, Location: infoPlistPath}, nil
+	}
+
+	if setting, ok := buildSettingReference(rawValue); ok {
+		return buildSettingVersion(settings, setting)
+	}
+
+	value, err := settings.expand(buildSettingValueString(rawValue))
+	if err != nil {
+		return versionValue{}, err
+	}
+
+	return versionValue{Value: value, File: infoPlistPath, Location: infoPlistPath}, nil
+}
+
+func buildSettingVersion(settings buildSettings, key string) (versionValue, error) {
+	value, err := settings.value(key)
+	if err != nil {
+		if _, ok := err.(undefinedBuildSettingError); ok {
+			return versionValue{}, nil
+		}
+		return versionValue{}, err
+	}
+
+	// The source is the build setting holding the literal value, if the value is a chain of references.
+	definition, _, err := settings.referencedDefinition(key, "")
+	if err != nil {
+		var ok bool
+		if definition, ok = settings.definition(key); !ok {
+			return versionValue{Value: value}, nil
+		}
+	}
+
+	file := filepath.Join(settings.builtins["PROJECT_FILE_PATH"], "project.pbxproj")
+	if definition.Xcconfig != nil {
+		file = definition.Xcconfig.Path
+	}
+
+	return versionValue{Value: value, File: file, Location: definition.Location()}, nil
+}
+
+// parseVersionComponents parses the period-separated non-negative integers of a version number.
+func parseVersionComponents(version string) ([]uint64, error) {
+	if version == "" {
+		return nil, errors.New("empty version number")
+	}
+
+	var components []uint64
+	for _, component := range strings.Split(version, ".") {
+		number, err := strconv.ParseUint(component, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version number component (%s) in %s", component, version)
+		}
+		components = append(components, number)
+	}
+
+	return components, nil
+}
+
+// compareVersions compares two version numbers component by component, the way App Store Connect orders them. Missing
+// components count as 0, so 1.2 equals 1.2.0.
+func compareVersions(a, b string) (int, error) {
+	aComponents, err := parseVersionComponents(a)
+	if err != nil {
+		return 0, err
+	}
+
+	bComponents, err := parseVersionComponents(b)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := 0; i < len(aComponents) || i < len(bComponents); i++ {
+		var aComponent, bComponent uint64
+		if i < len(aComponents) {
+			aComponent = aComponents[i]
+		}
+		if i < len(bComponents) {
+			bComponent = bComponents[i]
+		}
+
+		if aComponent < bComponent {
+			return -1, nil
+		}
+		if aComponent > bComponent {
+			return 1, nil
+		}
+	}
+
+	return 0, nil
+}
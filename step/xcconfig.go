@@ -0,0 +1,194 @@
+package step
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	includeDirective         = "#include"
+	optionalIncludeDirective = "#include?"
+)
+
+// xcconfig is a parsed Xcode build configuration (.xcconfig) file.
+//
+// The original lines are kept, so a single value can be rewritten later without touching the comments, the ordering or
+// the includes of the file.
+type xcconfig struct {
+	Path    string
+	lines   []string
+	entries []xcconfigEntry
+}
+
+// xcconfigEntry is either an include directive or a build setting assignment in an xcconfig file.
+type xcconfigEntry struct {
+	line int
+
+	include  string
+	optional bool
+
+	key        string
+	condition  string
+	valueStart int
+	valueEnd   int
+}
+
+func (e xcconfigEntry) isInclude() bool {
+	return e.include != ""
+}
+
+func parseXcconfigContent(pth string, content []byte) (*xcconfig, error) {
+	config := &xcconfig{
+		Path:  pth,
+		lines: strings.Split(string(content), "\n"),
+	}
+
+	for i, line := range config.lines {
+		entry, ok, err := parseXcconfigLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", pth, i+1, err)
+		}
+		if !ok {
+			continue
+		}
+
+		entry.line = i
+		config.entries = append(config.entries, entry)
+	}
+
+	return config, nil
+}
+
+func parseXcconfigLine(line string) (xcconfigEntry, bool, error) {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || strings.HasPrefix(trimmed, "//") {
+		return xcconfigEntry{}, false, nil
+	}
+
+	if strings.HasPrefix(trimmed, includeDirective) {
+		optional := strings.HasPrefix(trimmed, optionalIncludeDirective)
+		rest := strings.TrimPrefix(trimmed, includeDirective)
+		rest = strings.TrimSpace(strings.TrimPrefix(rest, "?"))
+
+		if len(rest) < 2 || rest[0] != '"' {
+			return xcconfigEntry{}, false, fmt.Errorf("invalid include directive: %s", trimmed)
+		}
+		end := strings.Index(rest[1:], `"`)
+		if end < 0 {
+			return xcconfigEntry{}, false, fmt.Errorf("invalid include directive: %s", trimmed)
+		}
+
+		return xcconfigEntry{include: rest[1 : end+1], optional: optional}, true, nil
+	}
+
+	separator := assignmentSeparator(line)
+	if separator < 0 {
+		return xcconfigEntry{}, false, fmt.Errorf("invalid line: %s", trimmed)
+	}
+
+	key, condition := splitBuildSettingKey(line[:separator])
+	if key == "" {
+		return xcconfigEntry{}, false, fmt.Errorf("missing build setting name: %s", trimmed)
+	}
+
+	valueEnd := len(line)
+	if comment := strings.Index(line[separator+1:], "//"); comment >= 0 {
+		valueEnd = separator + 1 + comment
+	}
+	valueEnd = len(strings.TrimRight(line[:valueEnd], " \t\r;"))
+
+	valueStart := separator + 1
+	for valueStart < valueEnd && (line[valueStart] == ' ' || line[valueStart] == '\t') {
+		valueStart++
+	}
+
+	return xcconfigEntry{
+		key:        key,
+		condition:  condition,
+		valueStart: valueStart,
+		valueEnd:   valueEnd,
+	}, true, nil
+}
+
+func (c *xcconfig) value(entry xcconfigEntry) string {
+	return c.lines[entry.line][entry.valueStart:entry.valueEnd]
+}
+
+// setValue replaces the value of an assignment, keeping the rest of the line (key, spacing and trailing comment) as-is.
+func (c *xcconfig) setValue(entry xcconfigEntry, value string) {
+	line := c.lines[entry.line]
+	c.lines[entry.line] = line[:entry.valueStart] + value + line[entry.valueEnd:]
+
+	for i, e := range c.entries {
+		if e.line == entry.line {
+			c.entries[i].valueEnd = entry.valueStart + len(value)
+		}
+	}
+}
+
+func (c *xcconfig) content() []byte {
+	return []byte(strings.Join(c.lines, "\n"))
+}
+
+func (c *xcconfig) includePath(entry xcconfigEntry) string {
+	if filepath.IsAbs(entry.include) {
+		return entry.include
+	}
+	return filepath.Join(filepath.Dir(c.Path), entry.include)
+}
+
+// assignmentSeparator returns the index of the `=` separating the key from the value, skipping the ones in the
+// conditions of the key, like `[sdk=iphoneos*]`.
+func assignmentSeparator(line string) int {
+	depth := 0
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '[':
+			depth++
+		case ']':
+			depth--
+		case '=':
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitBuildSettingKey splits a build setting key like `MARKETING_VERSION[sdk=iphoneos*]` into the setting name and its
+// condition part.
+func splitBuildSettingKey(key string) (string, string) {
+	key = strings.TrimSpace(key)
+
+	conditionStart := strings.Index(key, "[")
+	if conditionStart < 0 {
+		return key, ""
+	}
+
+	condition := strings.Join(strings.Fields(key[conditionStart:]), "")
+	return strings.TrimSpace(key[:conditionStart]), condition
+}
+
+// conditionValue returns the value of a single condition, like `iphoneos*` for the `sdk` parameter of the
+// `[sdk=iphoneos*][arch=arm64]` condition.
+func conditionValue(condition, parameter string) string {
+	for _, part := range strings.Split(condition, "]") {
+		part = strings.TrimPrefix(part, "[")
+		separator := strings.Index(part, "=")
+		if separator < 0 {
+			continue
+		}
+		if part[:separator] == parameter {
+			return part[separator+1:]
+		}
+	}
+	return ""
+}
+
+func fileExists(pth string) bool {
+	_, err := os.Stat(pth)
+	return err == nil
+}
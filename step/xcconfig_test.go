@@ -0,0 +1,55 @@
+package step
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseXcconfigContent(t *testing.T) {
+	content := `// Shared settings
+#include "Base.xcconfig"
+#include? "Local.xcconfig"
+
+MARKETING_VERSION = 1.2.3 // the version
+CURRENT_PROJECT_VERSION[sdk=macosx*] = 42;
+OTHER_LDFLAGS = $(inherited) -ObjC
+`
+	config, err := parseXcconfigContent("Config.xcconfig", []byte(content))
+	require.NoError(t, err)
+	require.Len(t, config.entries, 5)
+
+	require.Equal(t, "Base.xcconfig", config.entries[0].include)
+	require.False(t, config.entries[0].optional)
+	require.Equal(t, "Local.xcconfig", config.entries[1].include)
+	require.True(t, config.entries[1].optional)
+
+	require.Equal(t, "MARKETING_VERSION", config.entries[2].key)
+	require.Equal(t, "1.2.3", config.value(config.entries[2]))
+
+	require.Equal(t, "CURRENT_PROJECT_VERSION", config.entries[3].key)
+	require.Equal(t, "[sdk=macosx*]", config.entries[3].condition)
+	require.Equal(t, "42", config.value(config.entries[3]))
+
+	require.Equal(t, "$(inherited) -ObjC", config.value(config.entries[4]))
+}
+
+func Test_splitBuildSettingKey(t *testing.T) {
+	tests := []struct {
+		key           string
+		wantName      string
+		wantCondition string
+	}{
+		{key: "MARKETING_VERSION", wantName: "MARKETING_VERSION", wantCondition: ""},
+		{key: " MARKETING_VERSION ", wantName: "MARKETING_VERSION", wantCondition: ""},
+		{key: "MARKETING_VERSION[sdk=iphoneos*]", wantName: "MARKETING_VERSION", wantCondition: "[sdk=iphoneos*]"},
+		{key: "MARKETING_VERSION[sdk=macosx*] [arch=arm64]", wantName: "MARKETING_VERSION", wantCondition: "[sdk=macosx*][arch=arm64]"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			name, condition := splitBuildSettingKey(tt.key)
+			require.Equal(t, tt.wantName, name)
+			require.Equal(t, tt.wantCondition, condition)
+		})
+	}
+}